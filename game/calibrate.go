@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"overlay/pkg/bluetooth"
+	"overlay/pkg/repo"
+)
+
+var spinDownTimeout = flag.Duration("spin-down-timeout", 2*time.Minute, "how long the rider has to finish the spin down")
+
+// calibrate zeroes the power meter and guides the rider through the
+// spin down of the trainer, the devices that don't support it are skipped.
+func calibrate() error {
+	deviceRepo, err := repo.NewDeviceRepo(dbPath())
+	if err != nil {
+		return err
+	}
+	defer deviceRepo.Close()
+
+	d, err := newDevice(deviceRepo)
+	if err != nil {
+		return err
+	}
+	d.Listen()
+
+	// the cranks have to stand still for the offset, so it goes first
+	offsetErr := compensateOffset(d)
+	spinDownErr := spinDown(d)
+
+	offsetSkipped := errors.Is(offsetErr, bluetooth.ErrCalibrationNotSupported)
+	spinDownSkipped := errors.Is(spinDownErr, bluetooth.ErrCalibrationNotSupported)
+	switch {
+	case offsetSkipped && spinDownSkipped:
+		return errors.New("none of the connected devices can be calibrated")
+	case offsetSkipped:
+		return spinDownErr
+	case spinDownSkipped:
+		return offsetErr
+	default:
+		return errors.Join(offsetErr, spinDownErr)
+	}
+}
+
+func compensateOffset(d *bluetooth.Device) error {
+	fmt.Println("Calibrating the power meter, don't touch the cranks...")
+	offset, err := d.CompensateOffset()
+	if errors.Is(err, bluetooth.ErrCalibrationNotSupported) {
+		fmt.Println("The power meter can't be calibrated, skipping it")
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not calibrate the power meter: %w", err)
+	}
+
+	fmt.Printf("Power meter calibrated, the offset is %d\n", offset)
+	return nil
+}
+
+func spinDown(d *bluetooth.Device) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *spinDownTimeout)
+	defer cancel()
+
+	s, err := d.StartSpinDown(ctx)
+	if errors.Is(err, bluetooth.ErrCalibrationNotSupported) {
+		fmt.Println("The trainer can't be calibrated, skipping it")
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("could not start the spin down: %w", err)
+	}
+
+	fmt.Printf("Speed up to between %.1f and %.1fkm/h\n", float64(s.Target.Low)/1000, float64(s.Target.High)/1000)
+	go printSpeed(ctx, d)
+
+	for {
+		status, err := s.Next(ctx)
+		if err != nil {
+			return fmt.Errorf("spin down did not finish: %w", err)
+		}
+
+		switch status {
+		case bluetooth.SpinDownStopPedaling:
+			fmt.Println("\nStop pedaling and let the wheel coast...")
+		case bluetooth.SpinDownSuccess:
+			fmt.Println("\nSpin down done, the trainer is calibrated")
+			return nil
+		}
+	}
+}
+
+// printSpeed shows the speed on one line until ctx is done
+func printSpeed(ctx context.Context, d *bluetooth.Device) {
+	if d.Speed == nil {
+		return
+	}
+
+	sub, ok := d.Speed.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
+
+	for sample := range sub.C {
+		fmt.Printf("\r%5.1fkm/h", float64(sample.Value)/1000)
+	}
+}
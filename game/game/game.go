@@ -1,6 +1,7 @@
 package game
 
 import (
+	"context"
 	"log"
 	"sync/atomic"
 	"time"
@@ -19,10 +20,14 @@ type game struct {
 	width   int
 	height  int
 	sprites []sprites.Spriter
-	start   time.Time
-	timer   time.Time
+	// overlays are also updated when the game is paused
+	overlays []sprites.Spriter
+	start    time.Time
+	timer    time.Time
 
 	trainer *bluetooth.Device
+	// targets are sent to the trainer without waiting for it
+	targets *bluetooth.TargetWriter
 	State   state.GameState
 	opts    Opts
 }
@@ -34,6 +39,10 @@ type Opts struct {
 	// Determines how fast the game moves, the default is one second.
 	// This tickDuration exists mainly for testing purposes
 	TickDuration time.Duration
+
+	// Determines where the battery levels of the devices come from,
+	// by default the game follows them on its own.
+	BatteryLevels *bluetooth.BatteryLevels
 }
 
 func WithHeadless(headless bool) func(opts *Opts) {
@@ -48,6 +57,12 @@ func WithTickDuration(tickDuration time.Duration) func(opts *Opts) {
 	}
 }
 
+func WithBatteryLevels(levels *bluetooth.BatteryLevels) func(opts *Opts) {
+	return func(opts *Opts) {
+		opts.BatteryLevels = levels
+	}
+}
+
 func NewOpts(optsArgs ...func(opts *Opts)) Opts {
 	opts := Opts{
 		Headless:     false,
@@ -66,30 +81,32 @@ func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func (g *game) Update() error {
+	g.State.Battery = lowestBattery(g.opts.BatteryLevels)
+	for _, o := range g.overlays {
+		o.Update(g.State)
+	}
+
 	if g.State.Progress.Pause {
 		return nil
 	}
 
 	now := time.Now()
 	prevTimer := g.timer
-	prevPower := workout.TrainingPowerAt(g.State.Training, g.State.Progress.Duration())
 
 	if now.Sub(prevTimer) > g.opts.TickDuration {
 		g.timer = now
 		g.State.Progress.Tick()
 
-		newPower := workout.TrainingPowerAt(g.State.Training, g.State.Progress.Duration())
-
-		// check if we changed the power or the game just started
-		if prevPower != newPower || g.State.Progress.Duration() == 1*time.Second {
-			_, err := g.trainer.Power.Write(
-				newPower,
-			)
-			if err != nil {
-				slog.Error("could not write power: ", err)
-			}
+		// free rides have no target, the trainer should not be in ERG mode
+		segment, _ := workout.TrainingSegmentAt(g.State.Training, g.State.Progress.Duration())
+		target := bluetooth.Target{
+			Power:    workout.TrainingPowerAt(g.State.Training, g.State.Progress.Duration()),
+			FreeRide: segment != nil && segment.IsFreeRide(),
 		}
 
+		// only a changed target is sent to the trainer
+		g.targets.Set(target)
+
 		for _, s := range g.sprites {
 			s.Update(g.State)
 		}
@@ -111,6 +128,10 @@ func (g *game) Draw(screen *ebiten.Image) {
 	for _, s := range g.sprites {
 		s.Draw(screen)
 	}
+
+	for _, o := range g.overlays {
+		o.Draw(screen)
+	}
 }
 
 func getCurrentMonitorSize() (int, int) {
@@ -145,6 +166,21 @@ func NewGame(training *workout.Workout, trainer *bluetooth.Device, opts Opts) *g
 		slog.Error("could not create step timer: ", err)
 	}
 
+	cadence, err := sprites.NewCadence()
+	if err != nil {
+		slog.Error("could not create cadence: ", err)
+	}
+
+	reconnecting, err := sprites.NewReconnecting()
+	if err != nil {
+		slog.Error("could not create reconnecting banner: ", err)
+	}
+
+	lowBattery, err := sprites.NewLowBattery()
+	if err != nil {
+		slog.Error("could not create low battery banner: ", err)
+	}
+
 	game := &game{
 		width:  w,
 		height: h,
@@ -156,29 +192,52 @@ func NewGame(training *workout.Workout, trainer *bluetooth.Device, opts Opts) *g
 			totalTimer,
 			power,
 			stepTimer,
+			cadence,
+		},
+		overlays: []sprites.Spriter{
+			reconnecting,
+			lowBattery,
 		},
 		State: state.GameState{
 			Progress: state.NewProgress(),
 			Training: *training,
+			Battery:  state.Battery{Level: -1},
 		},
 		trainer: trainer,
+		targets: bluetooth.NewTargetWriter(trainer),
 		opts:    opts,
 	}
 
 	return game
 }
 
-func (g *game) subscribe(tr *bluetooth.Device) {
+// subscribe listens to the metrics of the trainer until ctx is done
+func (g *game) subscribe(ctx context.Context, tr *bluetooth.Device) {
 	var pauseCounter atomic.Int32
-	g.subscribePwr(tr, &pauseCounter)
-	g.subscribeCadence(tr)
+	g.subscribePwr(ctx, tr, &pauseCounter)
+	g.subscribeCadence(ctx, tr)
+	g.subscribeSpeed(ctx, tr)
+	g.subscribeHr(ctx, tr)
+	g.subscribeConnection(ctx, tr)
+
+	if g.opts.BatteryLevels == nil {
+		g.opts.BatteryLevels = bluetooth.WatchBatteryLevels(ctx, tr)
+	}
 
 	// if ten subsequent readings were zero,
 	// we pause the game
 	go func() {
-		for range time.Tick(1 * time.Second) {
-			if pauseCounter.Load() >= 5 {
-				g.State.Progress.Pause = true
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if pauseCounter.Load() >= 5 {
+					g.State.Progress.Pause = true
+				}
 			}
 		}
 	}()
@@ -195,8 +254,11 @@ func Run(training *workout.Workout, trainer *bluetooth.Device, opts Opts) {
 	op := &ebiten.RunGameOptions{}
 	op.ScreenTransparent = true
 	op.SkipTaskbar = true
-	// subscribe to all trainer metrics
-	game.subscribe(trainer)
+	// subscribe to all trainer metrics and write the targets while the game runs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	game.subscribe(ctx, trainer)
+	go game.targets.Run(ctx)
 
 	if err := ebiten.RunGameWithOptions(game, op); err != nil {
 		log.Fatal(err)
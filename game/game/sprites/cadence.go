@@ -0,0 +1,98 @@
+package sprites
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"overlay/game/state"
+	"overlay/internal/workout"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var (
+	cadenceInTarget  = color.RGBA{89, 189, 89, 255}
+	cadenceOffTarget = color.RGBA{237, 99, 52, 255}
+)
+
+// cadence shows the live cadence, next to it the cadence
+// target of the current segment when it has one.
+type cadence struct {
+	font       font.Face
+	targetFont font.Face
+	text       string
+	target     string
+	color      color.Color
+}
+
+func NewCadence() (*cadence, error) {
+	tt, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    48,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	targetFont, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    24,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cadence{
+		font:       f,
+		targetFont: targetFont,
+		text:       "0",
+		color:      color.White,
+	}, nil
+}
+
+func (c *cadence) Update(s state.GameState) {
+	c.text = strconv.Itoa(s.Metrics.Cadence)
+	c.target = ""
+	c.color = color.White
+
+	segment, _ := workout.TrainingSegmentAt(s.Training, s.Progress.Duration())
+	if segment == nil || !segment.HasCadence() {
+		return
+	}
+
+	c.target = formatCadenceTarget(*segment)
+	c.color = cadenceOffTarget
+	if s.Metrics.Cadence >= segment.CadenceLow && s.Metrics.Cadence <= segment.CadenceHigh {
+		c.color = cadenceInTarget
+	}
+}
+
+func (c *cadence) Draw(screen *ebiten.Image) {
+	dx := len(c.text) * 20
+	x := screen.Bounds().Dx() - 200 - dx
+	text.Draw(screen, c.text, c.font, x, 160, c.color)
+	text.Draw(screen, "rpm", c.targetFont, x+dx+10, 160, color.White)
+
+	if c.target != "" {
+		text.Draw(screen, c.target, c.targetFont, x+dx+60, 160, color.White)
+	}
+}
+
+func formatCadenceTarget(s workout.WorkoutSegment) string {
+	if s.CadenceLow == s.CadenceHigh {
+		return fmt.Sprintf("/ %d", s.CadenceHigh)
+	}
+	return fmt.Sprintf("/ %d-%d", s.CadenceLow, s.CadenceHigh)
+}
@@ -1,6 +1,8 @@
 package sprites
 
 import (
+	"fmt"
+	"image/color"
 	"overlay/game/state"
 	"overlay/internal/workout"
 	"time"
@@ -8,7 +10,9 @@ import (
 	gameColor "overlay/internal/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
 )
 
 type graph struct {
@@ -43,22 +47,34 @@ func (m *graph) Draw(screen *ebiten.Image) {
 	t := m.training
 	totalDuration := workout.Duration(t)
 
-	_, currentSegmentIndex := workout.TrainingSegmentAt(t, m.gameState.Progress.Duration())
+	current, ok := workout.TrainingStepAt(t, m.gameState.Progress.Duration())
+	currentSegmentIndex := -1
+	if ok {
+		currentSegmentIndex = current.Index
+	}
 
 	x := m.x
-	for i, s := range t.Segments {
-		c := gameColor.PowerToColor(((float64(s.StartPower) + float64(s.EndPower)) / 2), float64(t.FTP))
+	for step := range workout.Steps(t) {
+		i, s := step.Index, step.Segment
+		startPower, endPower := s.StartWatts(t.FTP), s.EndWatts(t.FTP)
+		c := gameColor.PowerToColor(((float64(startPower) + float64(endPower)) / 2), float64(t.FTP))
 		if i == currentSegmentIndex {
 			if int(m.gameState.Progress.Duration().Seconds())%2 == 1 {
 				c.A = 0
 			}
 		}
 
+		if i == currentSegmentIndex && current.Intervals > 0 {
+			// show which repetition of the block is ridden above the graph
+			label := fmt.Sprintf("%d/%d", current.Interval, current.Intervals)
+			text.Draw(screen, label, basicfont.Face7x13, x, screenHeight-screenHeight/15-10, color.White)
+		}
+
 		w := scaleWidth(s, totalDuration, m.Width)
-		if s.StartPower != s.EndPower {
-			rico := float64(s.EndPower-s.StartPower) / float64(w)
+		if startPower != endPower {
+			rico := float64(endPower-startPower) / float64(w)
 			for j := range w {
-				p := rico*float64(j) + float64(s.StartPower)
+				p := rico*float64(j) + float64(startPower)
 				h := scaleHeight(t, p, screenHeight)
 				vector.DrawFilledRect(
 					screen,
@@ -74,7 +90,7 @@ func (m *graph) Draw(screen *ebiten.Image) {
 			continue
 		}
 
-		h := scaleHeightAtIndex(t, i, screenHeight)
+		h := scaleHeightOfSegment(t, s, screenHeight)
 		vector.DrawFilledRect(
 			screen,
 			float32(x),
@@ -96,9 +112,13 @@ func scaleWidth(s workout.WorkoutSegment, totalDuration time.Duration, totalWidt
 	return int(frac * float64(totalWidth))
 }
 
-// scaleHeightAtIndex calculates the height of a training block depending on the screen height
-func scaleHeightAtIndex(s workout.Workout, index int, screenHeight int) int {
-	p := float64(s.Segments[index].EndPower)
+// scaleHeightOfSegment calculates the height of a training block depending on the screen height
+func scaleHeightOfSegment(s workout.Workout, segment workout.WorkoutSegment, screenHeight int) int {
+	p := float64(segment.EndWatts(s.FTP))
+	if segment.IsFreeRide() {
+		// a free ride has no target, show it as an easy block
+		p = float64(s.FTP) / 2
+	}
 	return scaleHeight(s, p, screenHeight)
 }
 
@@ -0,0 +1,60 @@
+package sprites
+
+import (
+	"fmt"
+	"image/color"
+
+	"overlay/game/state"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var lowBatteryBackground = color.RGBA{230, 180, 40, 220}
+
+// lowBattery is a banner that is shown while one
+// of the connected devices has a low battery
+type lowBattery struct {
+	font    font.Face
+	text    string
+	visible bool
+}
+
+func NewLowBattery() (*lowBattery, error) {
+	tt, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    28,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &lowBattery{font: f}, nil
+}
+
+func (b *lowBattery) Update(s state.GameState) {
+	b.visible = s.Battery.Low()
+	b.text = fmt.Sprintf("Low battery: %s at %d%%", s.Battery.Source, s.Battery.Level)
+}
+
+func (b *lowBattery) Draw(screen *ebiten.Image) {
+	if !b.visible {
+		return
+	}
+
+	// below the reconnecting banner, both can be shown at once
+	x, _ := CoordCenterRectStart(bannerWidth, screen.Bounds().Dx())
+	y := 20 + bannerHeight + 10
+	vector.DrawFilledRect(screen, float32(x), float32(y), bannerWidth, bannerHeight, lowBatteryBackground, false)
+	text.Draw(screen, b.text, b.font, x+20, y+40, color.Black)
+}
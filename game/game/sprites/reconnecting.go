@@ -0,0 +1,67 @@
+package sprites
+
+import (
+	"fmt"
+	"image/color"
+
+	"overlay/game/state"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var bannerBackground = color.RGBA{237, 99, 52, 220}
+
+const (
+	bannerWidth  = 500
+	bannerHeight = 60
+)
+
+// reconnecting is a banner that is shown
+// while the trainer is being reconnected
+type reconnecting struct {
+	font    font.Face
+	text    string
+	visible bool
+}
+
+func NewReconnecting() (*reconnecting, error) {
+	tt, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    28,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reconnecting{font: f}, nil
+}
+
+func (r *reconnecting) Update(s state.GameState) {
+	r.visible = s.Connection.Reconnecting
+	r.text = "Reconnecting to trainer..."
+	if s.Connection.Attempt > 0 {
+		r.text = fmt.Sprintf("Reconnecting to trainer (attempt %d)...", s.Connection.Attempt+1)
+	}
+}
+
+func (r *reconnecting) Draw(screen *ebiten.Image) {
+	if !r.visible {
+		return
+	}
+
+	x, _ := CoordCenterRectStart(bannerWidth, screen.Bounds().Dx())
+	y := 20
+	vector.DrawFilledRect(screen, float32(x), float32(y), bannerWidth, bannerHeight, bannerBackground, false)
+	text.Draw(screen, r.text, r.font, x+20, y+40, color.White)
+}
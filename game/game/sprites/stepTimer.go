@@ -16,8 +16,9 @@ import (
 )
 
 type StepTimer struct {
-	font font.Face
-	text string
+	font     font.Face
+	text     string
+	interval string
 }
 
 func NewStepTimer() (*StepTimer, error) {
@@ -42,24 +43,24 @@ func NewStepTimer() (*StepTimer, error) {
 }
 
 func (t *StepTimer) Update(s state.GameState) {
-	segment, index := workout.TrainingSegmentAt(s.Training, s.Progress.Duration())
-	if segment == nil {
+	step, ok := workout.TrainingStepAt(s.Training, s.Progress.Duration())
+	if !ok {
 		t.text = "00:00"
+		t.interval = ""
 		return
 	}
 
-	var timeInPreviousSegments time.Duration
-	for i := 0; i < index; i++ {
-		timeInPreviousSegments += s.Training.Segments[i].Duration
-	}
-
-	timeInCurrentSegment := s.Progress.Duration() - timeInPreviousSegments
-	remainingTime := segment.Duration - timeInCurrentSegment
+	timeInCurrentSegment := s.Progress.Duration() - step.Start
+	remainingTime := step.Segment.Duration - timeInCurrentSegment
 	t.text = formatStepDuration(remainingTime)
+	t.interval = formatInterval(step)
 }
 
 func (t *StepTimer) Draw(screen *ebiten.Image) {
 	text.Draw(screen, t.text, t.font, 20, 150, color.White)
+	if t.interval != "" {
+		text.Draw(screen, t.interval, t.font, 20, 200, color.White)
+	}
 }
 
 func formatStepDuration(d time.Duration) string {
@@ -68,3 +69,12 @@ func formatStepDuration(d time.Duration) string {
 	seconds := totalSeconds % 60
 	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
+
+// formatInterval shows which repetition of a block is ridden,
+// it is empty when the step is not part of a block.
+func formatInterval(step workout.Step) string {
+	if step.Intervals == 0 {
+		return ""
+	}
+	return fmt.Sprintf("interval %d/%d", step.Interval, step.Intervals)
+}
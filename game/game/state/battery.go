@@ -0,0 +1,14 @@
+package state
+
+// Battery is the lowest battery level of the connected devices
+type Battery struct {
+	// Source is the role of the device with the lowest level
+	Source string
+	// Level in %, it is -1 when no device reports its battery level
+	Level int
+}
+
+// Low reports whether a device has to be charged soon
+func (b Battery) Low() bool {
+	return b.Level >= 0 && b.Level <= 20
+}
@@ -0,0 +1,8 @@
+package state
+
+// Connection is the state of the connection with the trainer
+type Connection struct {
+	Reconnecting bool
+	// Attempt is the number of failed reconnect attempts
+	Attempt int
+}
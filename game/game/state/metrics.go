@@ -5,4 +5,6 @@ type Metrics struct {
 	Power int
 	Speed int // in m/h -> so 30 000m/h = 30km/u
 	Hr    int
+
+	Cadence int // in rpm
 }
@@ -6,4 +6,7 @@ type GameState struct {
 	Metrics  Metrics
 	Progress Progress
 	Training workout.Workout
+
+	Connection Connection
+	Battery    Battery
 }
@@ -1,25 +1,30 @@
 package game
 
 import (
+	"context"
 	"log/slog"
 	"sync/atomic"
 
+	"overlay/game/state"
 	"overlay/pkg/bluetooth"
 )
 
 // TODO: this stuff should not be inside the game.
 // the game should only get the channels itself,
 // not a bluetooth trainer directly
-func (g *game) subscribePwr(tr *bluetooth.Device, pauseCounter *atomic.Int32) {
+func (g *game) subscribePwr(ctx context.Context, tr *bluetooth.Device, pauseCounter *atomic.Int32) {
 	if tr.Power == nil {
 		return
 	}
 
-	powerChan := make(chan int)
-	tr.Power.AddListener(powerChan)
+	power, ok := tr.Power.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
 
 	go func() {
-		for p := range powerChan {
+		for sample := range power.C {
+			p := sample.Value
 			// Start when a new reading comes in
 			if g.State.Progress.Pause && p > 0 {
 				g.State.Progress.Pause = false
@@ -35,40 +40,93 @@ func (g *game) subscribePwr(tr *bluetooth.Device, pauseCounter *atomic.Int32) {
 	}()
 }
 
-func (g *game) subscribeSpeed(tr *bluetooth.Device) {
+func (g *game) subscribeSpeed(ctx context.Context, tr *bluetooth.Device) {
 	if tr.Speed == nil {
 		slog.Info("Speed characteristic is nil on device")
 		return
 	}
 
-	speedChan := make(chan int)
-	g.trainer.Speed.AddListener(speedChan)
+	speed, ok := tr.Speed.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
+
 	go func() {
-		for p := range speedChan {
+		for sample := range speed.C {
 			// only start when first power comes in
-			if g.State.Progress.Pause && p > 0 {
+			if g.State.Progress.Pause && sample.Value > 0 {
 				g.State.Progress.Pause = true
 			}
-			g.State.Metrics.Speed = p
+			g.State.Metrics.Speed = sample.Value
 		}
 	}()
 }
 
-func (g *game) subscribeCadence(tr *bluetooth.Device) {
+func (g *game) subscribeCadence(ctx context.Context, tr *bluetooth.Device) {
 	if tr.Cadence == nil {
 		slog.Info("Cadence characteristic is nil on device")
 		return
 	}
 
-	cadChan := make(chan int)
-	g.trainer.Cadence.AddListener(cadChan)
+	cadence, ok := tr.Cadence.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
+
 	go func() {
-		for p := range cadChan {
+		for sample := range cadence.C {
 			// only start when first power comes in
-			if p > 0 {
+			if sample.Value > 0 {
 				g.State.Progress.Pause = false
 			}
-			g.State.Metrics.Speed = p
+			g.State.Metrics.Cadence = sample.Value
+		}
+	}()
+}
+
+func (g *game) subscribeHr(ctx context.Context, tr *bluetooth.Device) {
+	if tr.Hr == nil {
+		slog.Info("Heart rate characteristic is nil on device")
+		return
+	}
+
+	hr, ok := tr.Hr.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
+
+	go func() {
+		for sample := range hr.C {
+			g.State.Metrics.Hr = sample.Value
+		}
+	}()
+}
+
+func (g *game) subscribeConnection(ctx context.Context, tr *bluetooth.Device) {
+	events, ok := tr.SubscribeConnection(ctx, 1)
+	if !ok {
+		return
+	}
+
+	go func() {
+		for e := range events.C {
+			g.State.Connection = state.Connection{
+				Reconnecting: e.State == bluetooth.StateReconnecting,
+				Attempt:      e.Attempt,
+			}
 		}
 	}()
 }
+
+// lowestBattery is the device with the lowest battery level
+func lowestBattery(levels *bluetooth.BatteryLevels) state.Battery {
+	if levels == nil {
+		return state.Battery{Level: -1}
+	}
+
+	role, level := levels.Lowest()
+	if level < 0 {
+		return state.Battery{Level: -1}
+	}
+	return state.Battery{Source: role.String(), Level: level}
+}
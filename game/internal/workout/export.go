@@ -0,0 +1,191 @@
+package workout
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+var errNoFTP = errors.New("workout has no FTP")
+
+// WriteZwo writes the workout as a Zwift .zwo file.
+// A ramp at the start becomes a warmup, a ramp going down at
+// the end becomes a cooldown and all other ramps are written as a ramp.
+func WriteZwo(t Workout, out io.Writer) error {
+	if t.FTP <= 0 {
+		return errNoFTP
+	}
+
+	f := zwoFile{Name: t.Name, SportType: "bike"}
+	for i, s := range t.Segments {
+		if !s.IsBlock() {
+			f.Workout.Steps = append(f.Workout.Steps, zwoStepFrom(t, i, s))
+			continue
+		}
+
+		if step, ok := zwoIntervalsFrom(t, s); ok {
+			f.Workout.Steps = append(f.Workout.Steps, step)
+			continue
+		}
+
+		// zwo files don't know nested or longer blocks, write them out
+		block := Workout{FTP: t.FTP, Segments: []WorkoutSegment{s}}
+		for step := range Steps(block) {
+			f.Workout.Steps = append(f.Workout.Steps, zwoStepFrom(t, -1, step.Segment))
+		}
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "    ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, "\n")
+	return err
+}
+
+func zwoStepFrom(t Workout, i int, s WorkoutSegment) zwoStep {
+	step := zwoStep{Duration: s.Duration.Seconds()}
+	step.setCadence(s)
+
+	if s.IsFreeRide() {
+		step.XMLName.Local = "FreeRide"
+		return step
+	}
+
+	start, end := round(s.startFTP(t.FTP), 3), round(s.endFTP(t.FTP), 3)
+	if start == end {
+		step.XMLName.Local = "SteadyState"
+		step.Power = start
+		return step
+	}
+
+	switch {
+	case i == 0 && start < end:
+		step.XMLName.Local = "Warmup"
+	case i == len(t.Segments)-1 && start > end:
+		step.XMLName.Local = "Cooldown"
+	default:
+		step.XMLName.Local = "Ramp"
+	}
+
+	step.PowerLow = start
+	step.PowerHigh = end
+	return step
+}
+
+// zwoIntervalsFrom converts a block of an on and off segment to
+// IntervalsT. It returns false when the block has another shape.
+func zwoIntervalsFrom(t Workout, s WorkoutSegment) (zwoStep, bool) {
+	if len(s.Segments) != 2 {
+		return zwoStep{}, false
+	}
+
+	on, off := s.Segments[0], s.Segments[1]
+	if on.IsBlock() || off.IsBlock() || on.IsFreeRide() || off.IsFreeRide() ||
+		on.CadenceLow != on.CadenceHigh || off.CadenceLow != off.CadenceHigh {
+		return zwoStep{}, false
+	}
+
+	step := zwoStep{
+		XMLName:        xml.Name{Local: "IntervalsT"},
+		Repeat:         s.Repeat,
+		OnDuration:     on.Duration.Seconds(),
+		OffDuration:    off.Duration.Seconds(),
+		Cadence:        on.CadenceHigh,
+		CadenceResting: off.CadenceHigh,
+	}
+
+	onLow, onHigh := round(on.startFTP(t.FTP), 3), round(on.endFTP(t.FTP), 3)
+	if onLow == onHigh {
+		step.OnPower = onLow
+	} else {
+		step.PowerOnLow, step.PowerOnHigh = onLow, onHigh
+	}
+
+	offLow, offHigh := round(off.startFTP(t.FTP), 3), round(off.endFTP(t.FTP), 3)
+	if offLow == offHigh {
+		step.OffPower = offLow
+	} else {
+		step.PowerOffLow, step.PowerOffHi = offLow, offHigh
+	}
+
+	return step, true
+}
+
+// WriteErg writes the workout as an .erg file, which
+// describes the workout in absolute watts. Relative segments
+// are resolved against the FTP of the workout.
+func WriteErg(t Workout, out io.Writer) error {
+	if t.FTP <= 0 {
+		return errNoFTP
+	}
+
+	return writeCourse(t, out, "WATTS", func(frac float64) float64 {
+		return frac * float64(t.FTP)
+	})
+}
+
+// WriteMrc writes the workout as an .mrc file, which
+// describes the workout in percentages of the FTP.
+func WriteMrc(t Workout, out io.Writer) error {
+	if t.FTP <= 0 {
+		return errNoFTP
+	}
+
+	return writeCourse(t, out, "PERCENT", func(frac float64) float64 {
+		return frac * 100
+	})
+}
+
+// writeCourse writes the course format shared by .erg and .mrc files.
+// Every segment is written as a start and an end point, so a ramp
+// is just a segment with a different value at both points. Blocks
+// are written out since the format has no repeats and free rides
+// are written as 0 since there is no target.
+func writeCourse(t Workout, out io.Writer, unit string, value func(frac float64) float64) error {
+	w := bufio.NewWriter(out)
+
+	fmt.Fprintln(w, "[COURSE HEADER]")
+	fmt.Fprintln(w, "VERSION = 2")
+	fmt.Fprintln(w, "UNITS = ENGLISH")
+	fmt.Fprintf(w, "DESCRIPTION = %s\n", t.Name)
+	fmt.Fprintf(w, "FILE NAME = %s\n", t.Name)
+	fmt.Fprintf(w, "FTP = %d\n", t.FTP)
+	fmt.Fprintf(w, "MINUTES %s\n", unit)
+	fmt.Fprintln(w, "[END COURSE HEADER]")
+	fmt.Fprintln(w, "[COURSE DATA]")
+
+	for step := range Steps(t) {
+		s := step.Segment
+		fmt.Fprintf(w, "%s\t%s\n", formatMinutes(step.Start), formatNumber(value(s.startFTP(t.FTP))))
+		end := step.Start + s.Duration
+		fmt.Fprintf(w, "%s\t%s\n", formatMinutes(end), formatNumber(value(s.endFTP(t.FTP))))
+	}
+
+	fmt.Fprintln(w, "[END COURSE DATA]")
+	return w.Flush()
+}
+
+func formatMinutes(d time.Duration) string {
+	return strconv.FormatFloat(d.Minutes(), 'f', 2, 64)
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(round(v, 1), 'f', -1, 64)
+}
+
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,100 @@
+package workout_test
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"overlay/internal/workout"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func goldenWorkout(t *testing.T) workout.Workout {
+	w, err := workout.FromString("Golden;200;100-200-600;250-250-300@85-95;free-120;3x(300-300-60@100;100-100-60@85);180-80-300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *w
+}
+
+func TestExportGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(workout.Workout, io.Writer) error
+	}{
+		{name: "golden.zwo", write: workout.WriteZwo},
+		{name: "golden.erg", write: workout.WriteErg},
+		{name: "golden.mrc", write: workout.WriteMrc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(goldenWorkout(t), &buf); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), expected) {
+				t.Errorf("output does not match %s, got:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestZwoRoundTrip(t *testing.T) {
+	expected := goldenWorkout(t)
+
+	var buf bytes.Buffer
+	if err := workout.WriteZwo(expected, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := workout.FromZwo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual.FTP = expected.FTP
+	if workout.Duration(*actual) != workout.Duration(expected) {
+		t.Fatalf("Should last %s, got %s", workout.Duration(expected), workout.Duration(*actual))
+	}
+
+	for d := time.Duration(0); d < workout.Duration(expected); d += time.Second {
+		e, a := workout.TrainingPowerAt(expected, d), workout.TrainingPowerAt(*actual, d)
+		if e != a {
+			t.Fatalf("Power at %s should be %d, got %d", d, e, a)
+		}
+
+		es, _ := workout.TrainingSegmentAt(expected, d)
+		as, _ := workout.TrainingSegmentAt(*actual, d)
+		if es.Kind != as.Kind || es.CadenceLow != as.CadenceLow || es.CadenceHigh != as.CadenceHigh {
+			t.Fatalf("Segment at %s should be %+v, got %+v", d, es, as)
+		}
+	}
+}
+
+func TestExportWithoutFTP(t *testing.T) {
+	w := goldenWorkout(t)
+	w.FTP = 0
+
+	if err := workout.WriteZwo(w, io.Discard); err == nil {
+		t.Error("Should not export a zwo file without FTP")
+	}
+}
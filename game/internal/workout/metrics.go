@@ -0,0 +1,145 @@
+package workout
+
+import (
+	"math"
+	"time"
+)
+
+// Zone is a power zone, Low and High are fractions of the FTP.
+// The zones match the colors used by Zwift.
+type Zone struct {
+	Name string
+	Low  float64
+	High float64
+}
+
+var Zones = []Zone{
+	{Name: "Z1", Low: 0, High: 0.60},
+	{Name: "Z2", Low: 0.60, High: 0.76},
+	{Name: "Z3", Low: 0.76, High: 0.90},
+	{Name: "Z4", Low: 0.90, High: 1.05},
+	{Name: "Z5", Low: 1.05, High: 1.19},
+	{Name: "Z6", Low: 1.19, High: math.Inf(1)},
+}
+
+// Summary holds the planned metrics of a workout
+type Summary struct {
+	Duration            time.Duration
+	NormalizedPower     float64
+	IntensityFactor     float64
+	TrainingStressScore float64
+	// Work is the total work in kJ
+	Work float64
+	// TimeInZones holds the time spent in each of the Zones
+	TimeInZones []time.Duration
+}
+
+// Summarize calculates all planned metrics of the workout.
+// Free ride segments have no target and count as 0W.
+func Summarize(t Workout) Summary {
+	return Summary{
+		Duration:            Duration(t),
+		NormalizedPower:     NormalizedPower(t),
+		IntensityFactor:     IntensityFactor(t),
+		TrainingStressScore: TrainingStressScore(t),
+		Work:                Work(t),
+		TimeInZones:         TimeInZones(t),
+	}
+}
+
+// powerSamples returns the planned power for every second of the workout
+func powerSamples(t Workout) []float64 {
+	seconds := int(Duration(t) / time.Second)
+	samples := make([]float64, seconds)
+	for i := range seconds {
+		samples[i] = float64(TrainingPowerAt(t, time.Duration(i)*time.Second))
+	}
+	return samples
+}
+
+// NormalizedPower returns the planned normalized power in watts. It is the
+// fourth root of the mean of the fourth powers of the 30s rolling average.
+func NormalizedPower(t Workout) float64 {
+	samples := powerSamples(t)
+	if len(samples) == 0 {
+		return 0
+	}
+
+	const window = 30
+	if len(samples) < window {
+		return mean(samples)
+	}
+
+	var sum, total float64
+	for i, p := range samples {
+		sum += p
+		if i >= window {
+			sum -= samples[i-window]
+		}
+
+		if i >= window-1 {
+			total += math.Pow(sum/window, 4)
+		}
+	}
+
+	return math.Pow(total/float64(len(samples)-window+1), 0.25)
+}
+
+// IntensityFactor returns the normalized power relative to the FTP
+func IntensityFactor(t Workout) float64 {
+	if t.FTP <= 0 {
+		return 0
+	}
+	return NormalizedPower(t) / float64(t.FTP)
+}
+
+// TrainingStressScore returns the planned TSS, an hour at FTP equals 100
+func TrainingStressScore(t Workout) float64 {
+	if t.FTP <= 0 {
+		return 0
+	}
+
+	np := NormalizedPower(t)
+	intensity := np / float64(t.FTP)
+	return Duration(t).Seconds() * np * intensity / (float64(t.FTP) * 3600) * 100
+}
+
+// Work returns the total planned work in kJ
+func Work(t Workout) float64 {
+	var joules float64
+	for _, p := range powerSamples(t) {
+		joules += p
+	}
+	return joules / 1000
+}
+
+// TimeInZones returns the planned time spent in each of the Zones
+func TimeInZones(t Workout) []time.Duration {
+	zones := make([]time.Duration, len(Zones))
+	if t.FTP <= 0 {
+		return zones
+	}
+
+	for _, p := range powerSamples(t) {
+		zones[zoneOf(p/float64(t.FTP))] += time.Second
+	}
+	return zones
+}
+
+// zoneOf returns the index of the zone of a power relative to the ftp
+func zoneOf(ratio float64) int {
+	for i, z := range Zones {
+		if ratio < z.High {
+			return i
+		}
+	}
+	return len(Zones) - 1
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
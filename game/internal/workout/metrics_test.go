@@ -0,0 +1,54 @@
+package workout_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"overlay/internal/workout"
+)
+
+func TestSummarizeHourAtFTP(t *testing.T) {
+	w, err := workout.FromString("FTP;250;100%-100%-3600")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := workout.Summarize(*w)
+	if math.Abs(s.NormalizedPower-250) > 0.01 {
+		t.Errorf("NP should be 250, got %f", s.NormalizedPower)
+	}
+
+	if math.Abs(s.IntensityFactor-1) > 0.001 {
+		t.Errorf("IF should be 1, got %f", s.IntensityFactor)
+	}
+
+	if math.Abs(s.TrainingStressScore-100) > 0.1 {
+		t.Errorf("TSS should be 100, got %f", s.TrainingStressScore)
+	}
+
+	if math.Abs(s.Work-900) > 0.01 {
+		t.Errorf("Work should be 900kJ, got %f", s.Work)
+	}
+
+	if s.TimeInZones[3] != time.Hour {
+		t.Errorf("Should spend an hour in Z4, got %v", s.TimeInZones)
+	}
+}
+
+func TestNormalizedPowerOfIntervals(t *testing.T) {
+	w, err := workout.FromString("Intervals;200;10x(300-300-180;100-100-180)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// variable efforts weigh heavier than their average power
+	np := workout.NormalizedPower(*w)
+	if np <= 200 || np >= 300 {
+		t.Errorf("NP should be between the average and max power, got %f", np)
+	}
+
+	if work := workout.Work(*w); math.Abs(work-200*3600/1000) > 0.01 {
+		t.Errorf("Work should be 720kJ, got %f", work)
+	}
+}
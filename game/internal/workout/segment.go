@@ -1,13 +1,46 @@
 package workout
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type Watts int
 
+type SegmentKind int
+
+const (
+	// KindERG segments are ridden with the trainer in ERG mode,
+	// the trainer gets the power target of the segment.
+	KindERG SegmentKind = iota
+	// KindFreeRide segments have no power target, the
+	// trainer is not controlled and the rider decides.
+	KindFreeRide
+)
+
 type WorkoutSegment struct {
+	Kind       SegmentKind
 	Duration   time.Duration
 	StartPower Watts
 	EndPower   Watts
+
+	// CadenceLow and CadenceHigh are an optional cadence target
+	// in rpm, both are 0 when the segment has no cadence target.
+	CadenceLow  int
+	CadenceHigh int
+
+	// Relative segments express their target as a fraction of the FTP
+	// in StartFTP and EndFTP. The watts are resolved at run time
+	// against the FTP of the rider, StartPower and EndPower are ignored.
+	Relative bool
+	StartFTP float64
+	EndFTP   float64
+
+	// Repeat turns the segment into a block of Segments that are
+	// ridden Repeat times. The duration and targets of a block
+	// itself are not used, they come from its segments.
+	Repeat   int
+	Segments []WorkoutSegment
 }
 
 func NewSegment(d time.Duration, startW Watts, endW Watts) WorkoutSegment {
@@ -25,3 +58,125 @@ func NewBuildupSegment(d time.Duration, beginW Watts, endW Watts) WorkoutSegment
 		EndPower:   endW,
 	}
 }
+
+// NewRelativeSegment creates a segment with targets relative to the FTP,
+// 0.75 means 75% of the FTP.
+func NewRelativeSegment(d time.Duration, startFTP float64, endFTP float64) WorkoutSegment {
+	return WorkoutSegment{
+		Duration: d,
+		Relative: true,
+		StartFTP: startFTP,
+		EndFTP:   endFTP,
+	}
+}
+
+// NewFreeRideSegment creates a segment without a power target
+func NewFreeRideSegment(d time.Duration) WorkoutSegment {
+	return WorkoutSegment{
+		Kind:     KindFreeRide,
+		Duration: d,
+	}
+}
+
+// WithCadence returns the segment with a cadence target between low and high rpm
+func (s WorkoutSegment) WithCadence(low int, high int) WorkoutSegment {
+	s.CadenceLow = low
+	s.CadenceHigh = high
+	return s
+}
+
+// HasCadence reports whether the segment has a cadence target
+func (s WorkoutSegment) HasCadence() bool {
+	return s.CadenceHigh > 0
+}
+
+// IsFreeRide reports whether the segment has no power target
+func (s WorkoutSegment) IsFreeRide() bool {
+	return s.Kind == KindFreeRide
+}
+
+// NewRepeat creates a block that repeats the given segments n times
+func NewRepeat(n int, segments ...WorkoutSegment) WorkoutSegment {
+	return WorkoutSegment{
+		Repeat:   n,
+		Segments: segments,
+	}
+}
+
+// IsBlock reports whether the segment repeats other segments
+func (s WorkoutSegment) IsBlock() bool {
+	return s.Repeat > 0
+}
+
+// TotalDuration returns the duration of the segment,
+// for a block this includes all repetitions.
+func (s WorkoutSegment) TotalDuration() time.Duration {
+	if !s.IsBlock() {
+		return s.Duration
+	}
+
+	return time.Duration(s.Repeat) * segmentsDuration(s.Segments)
+}
+
+// stepCount returns the amount of steps the segment expands to
+func (s WorkoutSegment) stepCount() int {
+	if !s.IsBlock() {
+		return 1
+	}
+
+	n := 0
+	for _, c := range s.Segments {
+		n += c.stepCount()
+	}
+	return s.Repeat * n
+}
+
+func segmentsDuration(segments []WorkoutSegment) time.Duration {
+	d := 0 * time.Second
+	for _, s := range segments {
+		d += s.TotalDuration()
+	}
+	return d
+}
+
+// StartWatts returns the target at the start of the segment for the given ftp
+func (s WorkoutSegment) StartWatts(ftp int) Watts {
+	if s.Relative {
+		return toWatts(s.StartFTP, ftp)
+	}
+	return s.StartPower
+}
+
+// EndWatts returns the target at the end of the segment for the given ftp
+func (s WorkoutSegment) EndWatts(ftp int) Watts {
+	if s.Relative {
+		return toWatts(s.EndFTP, ftp)
+	}
+	return s.EndPower
+}
+
+// startFTP returns the target at the start of the segment as a fraction of the ftp
+func (s WorkoutSegment) startFTP(ftp int) float64 {
+	if s.IsFreeRide() {
+		return 0
+	}
+	if s.Relative {
+		return s.StartFTP
+	}
+	return float64(s.StartPower) / float64(ftp)
+}
+
+// endFTP returns the target at the end of the segment as a fraction of the ftp
+func (s WorkoutSegment) endFTP(ftp int) float64 {
+	if s.IsFreeRide() {
+		return 0
+	}
+	if s.Relative {
+		return s.EndFTP
+	}
+	return float64(s.EndPower) / float64(ftp)
+}
+
+func toWatts(frac float64, ftp int) Watts {
+	return Watts(math.Round(frac * float64(ftp)))
+}
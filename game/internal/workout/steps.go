@@ -0,0 +1,103 @@
+package workout
+
+import (
+	"iter"
+	"time"
+)
+
+// Step is a single segment of a workout in which all
+// blocks are expanded, it is what the rider actually rides.
+type Step struct {
+	Segment WorkoutSegment
+
+	// Index is the position of the step in the expanded workout
+	Index int
+
+	// Start is the time in the workout at which the step starts
+	Start time.Duration
+
+	// Interval is the repetition, starting from 1, of the innermost block
+	// the step belongs to and Intervals is the amount of repetitions of
+	// that block. Both are 0 when the step is not part of a block.
+	Interval  int
+	Intervals int
+}
+
+// Steps iterates over the workout with all blocks expanded.
+// The expansion happens lazily while iterating.
+func Steps(t Workout) iter.Seq[Step] {
+	return func(yield func(Step) bool) {
+		step := Step{}
+		walkSteps(t.Segments, &step, yield)
+	}
+}
+
+// walkSteps yields every step of the segments, step keeps track
+// of the index and start time of the next step. It returns false
+// when the iteration was stopped.
+func walkSteps(segments []WorkoutSegment, step *Step, yield func(Step) bool) bool {
+	for _, s := range segments {
+		if !s.IsBlock() {
+			step.Segment = s
+			if !yield(*step) {
+				return false
+			}
+
+			step.Index++
+			step.Start += s.Duration
+			continue
+		}
+
+		interval, intervals := step.Interval, step.Intervals
+		for i := range s.Repeat {
+			step.Interval, step.Intervals = i+1, s.Repeat
+			if !walkSteps(s.Segments, step, yield) {
+				return false
+			}
+		}
+		step.Interval, step.Intervals = interval, intervals
+	}
+
+	return true
+}
+
+// TrainingStepAt returns the step that is ridden at time t. Blocks
+// before t are skipped without expanding them. It returns false when
+// t is outside of the workout.
+func TrainingStepAt(training Workout, t time.Duration) (Step, bool) {
+	if t < 0 {
+		return Step{}, false
+	}
+
+	step := Step{}
+	return stepAt(training.Segments, t, step)
+}
+
+func stepAt(segments []WorkoutSegment, t time.Duration, step Step) (Step, bool) {
+	for _, s := range segments {
+		d := s.TotalDuration()
+		if t >= d {
+			t -= d
+			step.Start += d
+			step.Index += s.stepCount()
+			continue
+		}
+
+		if !s.IsBlock() {
+			step.Segment = s
+			return step, true
+		}
+
+		// skip the repetitions that are already done
+		iterationDuration := segmentsDuration(s.Segments)
+		iteration := int(t / iterationDuration)
+		t -= time.Duration(iteration) * iterationDuration
+		step.Start += time.Duration(iteration) * iterationDuration
+		step.Index += iteration * (s.stepCount() / s.Repeat)
+		step.Interval, step.Intervals = iteration+1, s.Repeat
+
+		return stepAt(s.Segments, t, step)
+	}
+
+	return Step{}, false
+}
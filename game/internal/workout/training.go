@@ -22,50 +22,206 @@ func New() Workout {
 	}
 }
 
+// FromString parses a workout like "name;ftp;step;step;...".
+// When the string can not be parsed a *StepError is returned,
+// which tells which step and token caused the problem.
 func FromString(workoutString string) (*Workout, error) {
 	workout := New()
-	w := []WorkoutSegment{}
 
-	info := strings.Split(workoutString, ";")
+	info := splitSteps(workoutString)
 	// first two steps are reserved for workout name
 	// and ftp value
+	if len(info) < 2 || info[1] == "" {
+		return nil, &StepError{Err: ErrMissingFTP}
+	}
 
 	name := info[0]
 	ftpStr := info[1]
 	ftp, err := strconv.Atoi(ftpStr)
-	if err != nil {
-		return nil, err
+	if err != nil || ftp <= 0 {
+		return nil, &StepError{Token: ftpStr, Err: ErrInvalidFTP}
 	}
 
 	workout.FTP = ftp
 	workout.Name = name
 
-	workoutSteps := info[2:]
+	for i, step := range info[2:] {
+		segment, err := parseSegment(step)
+		if err != nil {
+			var stepErr *StepError
+			if errors.As(err, &stepErr) {
+				stepErr.Step = i + 1
+			}
+			return nil, err
+		}
+		workout.Segments = append(workout.Segments, segment)
+	}
+
+	if err := errors.Join(validateSteps(workout)...); err != nil {
+		return nil, err
+	}
+
+	return &workout, nil
+}
+
+// splitSteps splits a workout string on ";", separators
+// inside a repeat block like 6x(...;...) are kept.
+func splitSteps(s string) []string {
+	steps := []string{}
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ';':
+			if depth == 0 {
+				steps = append(steps, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(steps, s[start:])
+}
+
+func parseSegments(workoutSteps []string) ([]WorkoutSegment, error) {
+	w := []WorkoutSegment{}
 	for _, s := range workoutSteps {
-		powerDuration := strings.Split(s, "-")
-		startPower := powerDuration[0]
-		endPower := powerDuration[1]
-		duration := powerDuration[2]
+		segment, err := parseSegment(s)
+		if err != nil {
+			return nil, err
+		}
+		w = append(w, segment)
+	}
 
-		pStartInt, err := strconv.Atoi(startPower)
+	return w, nil
+}
+
+// parseSegment parses a single step, which is either
+// start-end-duration, free-duration for a free ride or a block
+// like 6x(step;step). A cadence target can be added to a step
+// with @low-high or @rpm, for example 200-200-60@90-100.
+func parseSegment(s string) (WorkoutSegment, error) {
+	if step, cadence, ok := strings.Cut(s, "@"); ok && !strings.Contains(s, "(") {
+		segment, err := parseSegment(step)
 		if err != nil {
-			return nil, errors.New("could not parse workout")
+			return WorkoutSegment{}, err
 		}
-		pEndInt, err := strconv.Atoi(endPower)
+
+		low, high, err := parseCadence(cadence)
 		if err != nil {
-			return nil, errors.New("could not parse workout")
+			return WorkoutSegment{}, tokenError(cadence, ErrInvalidCadence)
 		}
+		return segment.WithCadence(low, high), nil
+	}
 
-		durationInt, err := strconv.Atoi(duration)
+	if duration, ok := strings.CutPrefix(s, "free-"); ok {
+		d, err := parseDuration(duration)
 		if err != nil {
-			return nil, errors.New("could not parse workout")
+			return WorkoutSegment{}, err
 		}
-		w = append(w, NewSegment(time.Second*time.Duration(durationInt), Watts(pStartInt), Watts(pEndInt)))
+		return NewFreeRideSegment(d), nil
 	}
 
-	workout.Segments = w
+	if repeat, block, ok := strings.Cut(s, "x("); ok {
+		n, err := strconv.Atoi(repeat)
+		if err != nil || n <= 0 {
+			return WorkoutSegment{}, tokenError(repeat, ErrInvalidRepeat)
+		}
 
-	return &workout, nil
+		inner, ok := strings.CutSuffix(block, ")")
+		if !ok {
+			return WorkoutSegment{}, tokenError(s, ErrMalformedStep)
+		}
+
+		segments, err := parseSegments(splitSteps(inner))
+		if err != nil {
+			return WorkoutSegment{}, err
+		}
+		return NewRepeat(n, segments...), nil
+	}
+
+	powerDuration := strings.Split(s, "-")
+	if len(powerDuration) != 3 {
+		return WorkoutSegment{}, tokenError(s, ErrMalformedStep)
+	}
+
+	startPower := powerDuration[0]
+	endPower := powerDuration[1]
+	duration := powerDuration[2]
+
+	d, err := parseDuration(duration)
+	if err != nil {
+		return WorkoutSegment{}, err
+	}
+
+	// powers ending with % are relative to the ftp of the rider
+	if strings.HasSuffix(startPower, "%") || strings.HasSuffix(endPower, "%") {
+		pStart, err := parsePercentage(startPower)
+		if err != nil {
+			return WorkoutSegment{}, tokenError(startPower, ErrInvalidPower)
+		}
+		pEnd, err := parsePercentage(endPower)
+		if err != nil {
+			return WorkoutSegment{}, tokenError(endPower, ErrInvalidPower)
+		}
+
+		return NewRelativeSegment(d, pStart, pEnd), nil
+	}
+
+	pStartInt, err := strconv.Atoi(startPower)
+	if err != nil {
+		return WorkoutSegment{}, tokenError(startPower, ErrInvalidPower)
+	}
+	pEndInt, err := strconv.Atoi(endPower)
+	if err != nil {
+		return WorkoutSegment{}, tokenError(endPower, ErrInvalidPower)
+	}
+
+	return NewSegment(d, Watts(pStartInt), Watts(pEndInt)), nil
+}
+
+// parseDuration parses a duration in seconds
+func parseDuration(duration string) (time.Duration, error) {
+	durationInt, err := strconv.Atoi(duration)
+	if err != nil || durationInt <= 0 {
+		return 0, tokenError(duration, ErrInvalidDuration)
+	}
+	return time.Second * time.Duration(durationInt), nil
+}
+
+// parseCadence parses a cadence target like "90-100" or "90"
+func parseCadence(c string) (int, int, error) {
+	lowStr, highStr, ok := strings.Cut(c, "-")
+	if !ok {
+		highStr = lowStr
+	}
+
+	low, err := strconv.Atoi(lowStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := strconv.Atoi(highStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return low, high, nil
+}
+
+// parsePercentage parses a power like "75%" to a fraction of the ftp
+func parsePercentage(p string) (float64, error) {
+	v, ok := strings.CutSuffix(p, "%")
+	if !ok {
+		return 0, errors.New("power should be a percentage")
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	return f / 100, nil
 }
 
 func NewRandom() *Workout {
@@ -80,63 +236,65 @@ func NewRandom() *Workout {
 	}
 }
 
+// MinPower returns the lowest target of the workout,
+// free ride segments have no target and are skipped.
 func MinPower(t Workout) Watts {
 	m := Watts(math.MaxInt)
-	for _, s := range t.Segments {
-		if s.StartPower < m {
-			m = s.StartPower
-		}
-
-		if s.EndPower < m {
-			m = s.EndPower
+	for step := range Steps(t) {
+		s := step.Segment
+		if s.IsFreeRide() {
+			continue
 		}
+		m = min(m, s.StartWatts(t.FTP), s.EndWatts(t.FTP))
 	}
 	return m
 }
 
+// MaxPower returns the highest target of the workout,
+// free ride segments have no target and are skipped.
 func MaxPower(t Workout) Watts {
 	m := Watts(-1)
-	for _, s := range t.Segments {
-		if s.StartPower > m {
-			m = s.StartPower
-		}
-
-		if s.EndPower > m {
-			m = s.EndPower
+	for step := range Steps(t) {
+		s := step.Segment
+		if s.IsFreeRide() {
+			continue
 		}
+		m = max(m, s.StartWatts(t.FTP), s.EndWatts(t.FTP))
 	}
 	return m
 }
 
 func Duration(t Workout) time.Duration {
-	d := 0 * time.Second
-	for _, s := range t.Segments {
-		d += s.Duration
-	}
-
-	return d
+	return segmentsDuration(t.Segments)
 }
 
+// TrainingPowerAt returns the power target at time t. A free ride
+// has no target, 0 is returned for it.
 func TrainingPowerAt(training Workout, t time.Duration) int {
-	progr := t
-	for _, tr := range training.Segments {
-		if progr < tr.Duration {
-			rico := (float64(tr.EndPower) - float64(tr.StartPower)) / float64(tr.Duration)
-			p := int(rico*float64(progr)) + int(tr.StartPower)
-			return p
-		}
-		progr -= tr.Duration
+	step, ok := TrainingStepAt(training, t)
+	if !ok {
+		return -1
+	}
+
+	tr := step.Segment
+	if tr.IsFreeRide() {
+		return 0
 	}
-	return -1
+
+	progr := t - step.Start
+	startPower := tr.StartWatts(training.FTP)
+	endPower := tr.EndWatts(training.FTP)
+	rico := (float64(endPower) - float64(startPower)) / float64(tr.Duration)
+	p := int(rico*float64(progr)) + int(startPower)
+	return p
 }
 
+// TrainingSegmentAt returns the segment ridden at time t and
+// its index in the workout with all blocks expanded.
 func TrainingSegmentAt(training Workout, t time.Duration) (*WorkoutSegment, int) {
-	progr := t
-	for i, tr := range training.Segments {
-		if progr < tr.Duration {
-			return &tr, i
-		}
-		progr -= tr.Duration
+	step, ok := TrainingStepAt(training, t)
+	if !ok {
+		return nil, -1
 	}
-	return nil, -1
+	return &step.Segment, step.Index
 }
@@ -0,0 +1,176 @@
+package workout_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"overlay/internal/workout"
+)
+
+func TestRelativeSegments(t *testing.T) {
+	w, err := workout.FromString("Relative;250;50%-100%-600;120%-120%-300")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := workout.TrainingPowerAt(*w, 0); p != 125 {
+		t.Errorf("Should start at 125W, got %d", p)
+	}
+
+	if p := workout.MaxPower(*w); p != 300 {
+		t.Errorf("Max power should be 300W, got %d", p)
+	}
+
+	w.FTP = 300
+	if p := workout.MaxPower(*w); p != 360 {
+		t.Errorf("Max power should follow the FTP and be 360W, got %d", p)
+	}
+}
+
+func TestRepeatBlocks(t *testing.T) {
+	w, err := workout.FromString("Blocks;200;100-100-60;3x(300-300-30;100-100-30);2x(2x(200-200-10;150-150-10);120-120-20)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := workout.Duration(*w); d != 60*time.Second+180*time.Second+120*time.Second {
+		t.Fatalf("Should last 6 minutes, got %s", d)
+	}
+
+	steps := []workout.Step{}
+	for step := range workout.Steps(*w) {
+		steps = append(steps, step)
+	}
+
+	if len(steps) != 1+6+10 {
+		t.Fatalf("Should have 17 steps, got %d", len(steps))
+	}
+
+	// the lazy lookup should agree with the expanded workout
+	for d := time.Duration(0); d < workout.Duration(*w); d += time.Second {
+		step, ok := workout.TrainingStepAt(*w, d)
+		if !ok {
+			t.Fatalf("Should find a step at %s", d)
+		}
+
+		expected := steps[step.Index]
+		if step.Start != expected.Start || step.Interval != expected.Interval || step.Intervals != expected.Intervals {
+			t.Fatalf("Step at %s should be %+v, got %+v", d, expected, step)
+		}
+
+		if d < step.Start || d >= step.Start+step.Segment.Duration {
+			t.Fatalf("Step at %s starting at %s does not contain it", d, step.Start)
+		}
+	}
+
+	step, _ := workout.TrainingStepAt(*w, 60*time.Second+65*time.Second)
+	if step.Interval != 2 || step.Intervals != 3 {
+		t.Errorf("Should be in interval 2/3, got %d/%d", step.Interval, step.Intervals)
+	}
+
+	if p := workout.TrainingPowerAt(*w, 60*time.Second+65*time.Second); p != 300 {
+		t.Errorf("Should ride 300W, got %d", p)
+	}
+
+	if _, ok := workout.TrainingStepAt(*w, workout.Duration(*w)); ok {
+		t.Error("Should not find a step after the workout")
+	}
+}
+
+func TestFreeRideAndCadence(t *testing.T) {
+	w, err := workout.FromString("Free;200;free-60;200-200-60@90-100;150-150-60@85")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := workout.TrainingSegmentAt(*w, 0)
+	if !s.IsFreeRide() {
+		t.Error("First segment should be a free ride")
+	}
+
+	if p := workout.TrainingPowerAt(*w, 0); p != 0 {
+		t.Errorf("A free ride has no target, got %d", p)
+	}
+
+	if p := workout.MinPower(*w); p != 150 {
+		t.Errorf("Free rides should not count for the min power, got %d", p)
+	}
+
+	s, _ = workout.TrainingSegmentAt(*w, 90*time.Second)
+	if s.CadenceLow != 90 || s.CadenceHigh != 100 {
+		t.Errorf("Cadence target should be 90-100, got %d-%d", s.CadenceLow, s.CadenceHigh)
+	}
+
+	s, _ = workout.TrainingSegmentAt(*w, 150*time.Second)
+	if s.CadenceLow != 85 || s.CadenceHigh != 85 {
+		t.Errorf("Cadence target should be 85, got %d-%d", s.CadenceLow, s.CadenceHigh)
+	}
+}
+
+func TestFromStringErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		workout  string
+		step     int
+		token    string
+		expected error
+	}{
+		{name: "empty", workout: "", expected: workout.ErrMissingFTP},
+		{name: "no ftp", workout: "Test", expected: workout.ErrMissingFTP},
+		{name: "invalid ftp", workout: "Test;abc;100-100-60", token: "abc", expected: workout.ErrInvalidFTP},
+		{name: "no steps", workout: "Test;200", expected: workout.ErrNoSteps},
+		{name: "truncated step", workout: "Test;200;100-100-60;100-120", step: 2, token: "100-120", expected: workout.ErrMalformedStep},
+		{name: "invalid power", workout: "Test;200;1o0-100-60", step: 1, token: "1o0", expected: workout.ErrInvalidPower},
+		{name: "zero duration", workout: "Test;200;100-100-60;100-100-0", step: 2, token: "0", expected: workout.ErrInvalidDuration},
+		{name: "absurd power", workout: "Test;200;100-100-60;9000-9000-60", step: 2, expected: workout.ErrAbsurdPower},
+		{name: "absurd relative power", workout: "Test;200;900%-900%-60", step: 1, expected: workout.ErrAbsurdPower},
+		{name: "invalid cadence", workout: "Test;200;100-100-60@fast", step: 1, token: "fast", expected: workout.ErrInvalidCadence},
+		{name: "inverted cadence", workout: "Test;200;100-100-60@100-90", step: 1, expected: workout.ErrInvalidCadence},
+		{name: "invalid repeat", workout: "Test;200;0x(100-100-60)", step: 1, token: "0", expected: workout.ErrInvalidRepeat},
+		{name: "unclosed block", workout: "Test;200;3x(100-100-60;50-50-60", step: 1, expected: workout.ErrMalformedStep},
+		{name: "error inside block", workout: "Test;200;100-100-60;3x(100-100-60;50-50)", step: 2, token: "50-50", expected: workout.ErrMalformedStep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := workout.FromString(tt.workout)
+			if err == nil {
+				t.Fatalf("Should not parse, got %+v", w)
+			}
+
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Should fail with %q, got %q", tt.expected, err)
+			}
+
+			var stepErr *workout.StepError
+			if !errors.As(err, &stepErr) {
+				t.Fatalf("Should be a StepError, got %T", err)
+			}
+
+			if stepErr.Step != tt.step {
+				t.Errorf("Should fail at step %d, got %d", tt.step, stepErr.Step)
+			}
+
+			if tt.token != "" && stepErr.Token != tt.token {
+				t.Errorf("Should fail on token %q, got %q", tt.token, stepErr.Token)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	w, err := workout.FromString("Test;200;50%-75%-600;100-100-60")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := workout.Validate(*w); err != nil {
+		t.Errorf("Should be valid, got %q", err)
+	}
+
+	w.FTP = 0
+	if err := workout.Validate(*w); !errors.Is(err, workout.ErrMissingFTP) {
+		t.Errorf("Should miss the FTP, got %q", err)
+	}
+}
@@ -0,0 +1,136 @@
+package workout
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// maxWatts is the highest target that makes sense on a trainer
+	maxWatts = 2500
+	// maxFTPFraction is the highest target relative to the FTP
+	maxFTPFraction = 5.0
+	// maxCadence is the highest cadence target in rpm
+	maxCadence = 200
+)
+
+var (
+	ErrMissingFTP      = errors.New("missing FTP")
+	ErrInvalidFTP      = errors.New("invalid FTP")
+	ErrNoSteps         = errors.New("workout has no steps")
+	ErrMalformedStep   = errors.New("malformed step")
+	ErrInvalidDuration = errors.New("duration should be a positive amount of seconds")
+	ErrInvalidPower    = errors.New("invalid power")
+	ErrAbsurdPower     = errors.New("power is out of range")
+	ErrInvalidCadence  = errors.New("invalid cadence")
+	ErrInvalidRepeat   = errors.New("invalid repeat")
+)
+
+// StepError reports a problem with a single step of a workout.
+type StepError struct {
+	// Step is the position of the step in the workout, starting at 1.
+	// It is 0 when the problem is in the name or FTP of the workout.
+	Step int
+	// Token is the part of the workout string that could not be parsed,
+	// it is empty when the error comes from validating a workout.
+	Token string
+	Err   error
+}
+
+func (e *StepError) Error() string {
+	pos := "workout"
+	if e.Step > 0 {
+		pos = fmt.Sprintf("step %d", e.Step)
+	}
+
+	if e.Token == "" {
+		return fmt.Sprintf("%s: %s", pos, e.Err)
+	}
+	return fmt.Sprintf("%s: %q: %s", pos, e.Token, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+func tokenError(token string, err error) *StepError {
+	return &StepError{Token: token, Err: err}
+}
+
+// Validate checks if the workout can be ridden. All problems
+// are returned, each of them as a *StepError.
+func Validate(t Workout) error {
+	errs := []error{}
+	if t.FTP <= 0 {
+		errs = append(errs, &StepError{Err: ErrMissingFTP})
+	}
+
+	errs = append(errs, validateSteps(t)...)
+	return errors.Join(errs...)
+}
+
+// validateSteps checks the segments of the workout,
+// relative targets are only checked when the FTP is known.
+func validateSteps(t Workout) []error {
+	if len(t.Segments) == 0 {
+		return []error{&StepError{Err: ErrNoSteps}}
+	}
+
+	errs := []error{}
+	for i, s := range t.Segments {
+		for _, err := range validateSegment(s, t.FTP) {
+			errs = append(errs, &StepError{Step: i + 1, Err: err})
+		}
+	}
+
+	return errs
+}
+
+func validateSegment(s WorkoutSegment, ftp int) []error {
+	errs := []error{}
+	if s.IsBlock() {
+		if len(s.Segments) == 0 {
+			errs = append(errs, fmt.Errorf("%w: block has no steps", ErrInvalidRepeat))
+		}
+		for _, c := range s.Segments {
+			errs = append(errs, validateSegment(c, ftp)...)
+		}
+		return errs
+	}
+
+	if s.Repeat < 0 {
+		errs = append(errs, fmt.Errorf("%w: %d", ErrInvalidRepeat, s.Repeat))
+	}
+
+	if s.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidDuration, s.Duration))
+	}
+
+	if s.CadenceLow < 0 || s.CadenceLow > s.CadenceHigh || s.CadenceHigh > maxCadence {
+		errs = append(errs, fmt.Errorf("%w: %d-%d", ErrInvalidCadence, s.CadenceLow, s.CadenceHigh))
+	}
+
+	if s.IsFreeRide() {
+		return errs
+	}
+
+	if s.Relative {
+		for _, f := range []float64{s.StartFTP, s.EndFTP} {
+			if f < 0 || f > maxFTPFraction {
+				errs = append(errs, fmt.Errorf("%w: %.0f%% of FTP", ErrAbsurdPower, f*100))
+			}
+		}
+
+		if ftp <= 0 {
+			return errs
+		}
+	}
+
+	for _, w := range []Watts{s.StartWatts(ftp), s.EndWatts(ftp)} {
+		if w < 0 || w > maxWatts {
+			errs = append(errs, fmt.Errorf("%w: %dW", ErrAbsurdPower, w))
+		}
+	}
+
+	return errs
+}
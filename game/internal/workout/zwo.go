@@ -0,0 +1,159 @@
+package workout
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// zwoFile mirrors the xml layout of a Zwift workout file.
+// Only the fields needed to build a Workout are mapped.
+type zwoFile struct {
+	XMLName   xml.Name `xml:"workout_file"`
+	Name      string   `xml:"name"`
+	SportType string   `xml:"sportType"`
+	Workout   struct {
+		Steps []zwoStep `xml:",any"`
+	} `xml:"workout"`
+}
+
+// zwoStep holds the attributes of every step element. Which
+// attributes are filled in depends on the element, the order of the
+// steps is kept since they are all collected in a single slice.
+type zwoStep struct {
+	XMLName     xml.Name
+	Duration    float64 `xml:"Duration,attr,omitempty"`
+	Power       float64 `xml:"Power,attr,omitempty"`
+	PowerLow    float64 `xml:"PowerLow,attr,omitempty"`
+	PowerHigh   float64 `xml:"PowerHigh,attr,omitempty"`
+	Repeat      int     `xml:"Repeat,attr,omitempty"`
+	OnDuration  float64 `xml:"OnDuration,attr,omitempty"`
+	OffDuration float64 `xml:"OffDuration,attr,omitempty"`
+	OnPower     float64 `xml:"OnPower,attr,omitempty"`
+	OffPower    float64 `xml:"OffPower,attr,omitempty"`
+	PowerOnLow  float64 `xml:"PowerOnLow,attr,omitempty"`
+	PowerOnHigh float64 `xml:"PowerOnHigh,attr,omitempty"`
+	PowerOffLow float64 `xml:"PowerOffLow,attr,omitempty"`
+	PowerOffHi  float64 `xml:"PowerOffHigh,attr,omitempty"`
+
+	Cadence        int `xml:"Cadence,attr,omitempty"`
+	CadenceLow     int `xml:"CadenceLow,attr,omitempty"`
+	CadenceHigh    int `xml:"CadenceHigh,attr,omitempty"`
+	CadenceResting int `xml:"CadenceResting,attr,omitempty"`
+}
+
+// FromZwo reads a Zwift .zwo workout. Power values in a zwo file
+// are fractions of the FTP, so all segments are relative and the FTP
+// of the workout has to be set before riding it.
+func FromZwo(r io.Reader) (*Workout, error) {
+	var f zwoFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("could not parse zwo workout: %w", err)
+	}
+
+	workout := New()
+	workout.Name = f.Name
+
+	for i, s := range f.Workout.Steps {
+		segments, err := s.segments()
+		if err != nil {
+			return nil, &StepError{Step: i + 1, Token: s.XMLName.Local, Err: err}
+		}
+		workout.Segments = append(workout.Segments, segments...)
+	}
+
+	if err := errors.Join(validateSteps(workout)...); err != nil {
+		return nil, err
+	}
+
+	return &workout, nil
+}
+
+// FromFile loads a workout from disk, the parser is picked
+// based on the extension of the file.
+func FromFile(path string) (*Workout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zwo":
+		return FromZwo(f)
+	case ".txt":
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return FromString(strings.TrimSpace(string(b)))
+	default:
+		return nil, fmt.Errorf("unsupported workout file %q", filepath.Base(path))
+	}
+}
+
+func (s zwoStep) segments() ([]WorkoutSegment, error) {
+	d := zwoDuration(s.Duration)
+
+	low, high := s.cadence()
+
+	switch s.XMLName.Local {
+	case "Warmup", "Cooldown", "Ramp":
+		return []WorkoutSegment{NewRelativeSegment(d, s.PowerLow, s.PowerHigh).WithCadence(low, high)}, nil
+	case "SteadyState":
+		p := s.Power
+		if p == 0 {
+			p = (s.PowerLow + s.PowerHigh) / 2
+		}
+		return []WorkoutSegment{NewRelativeSegment(d, p, p).WithCadence(low, high)}, nil
+	case "IntervalsT":
+		onLow, onHigh := s.OnPower, s.OnPower
+		if onLow == 0 {
+			onLow, onHigh = s.PowerOnLow, s.PowerOnHigh
+		}
+		offLow, offHigh := s.OffPower, s.OffPower
+		if offLow == 0 {
+			offLow, offHigh = s.PowerOffLow, s.PowerOffHi
+		}
+
+		return []WorkoutSegment{NewRepeat(
+			max(s.Repeat, 1),
+			NewRelativeSegment(zwoDuration(s.OnDuration), onLow, onHigh).WithCadence(low, high),
+			NewRelativeSegment(zwoDuration(s.OffDuration), offLow, offHigh).
+				WithCadence(s.CadenceResting, s.CadenceResting),
+		)}, nil
+	case "FreeRide", "MaxEffort":
+		return []WorkoutSegment{NewFreeRideSegment(d).WithCadence(low, high)}, nil
+	case "textevent":
+		return nil, nil
+	default:
+		return nil, ErrMalformedStep
+	}
+}
+
+// cadence returns the cadence target of the step, a single
+// Cadence attribute is a target range of one rpm.
+func (s zwoStep) cadence() (int, int) {
+	if s.Cadence > 0 {
+		return s.Cadence, s.Cadence
+	}
+	return s.CadenceLow, s.CadenceHigh
+}
+
+// setCadence writes the cadence target of the segment to the step
+func (s *zwoStep) setCadence(segment WorkoutSegment) {
+	if segment.CadenceLow == segment.CadenceHigh {
+		s.Cadence = segment.CadenceHigh
+		return
+	}
+	s.CadenceLow, s.CadenceHigh = segment.CadenceLow, segment.CadenceHigh
+}
+
+func zwoDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,149 @@
+package workout_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"overlay/internal/workout"
+)
+
+// zwo wraps steps in a workout file
+func zwo(steps string) string {
+	return `<workout_file><name>Test</name><sportType>bike</sportType><workout>` + steps + `</workout></workout_file>`
+}
+
+func TestFromZwo(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    string
+		expected []workout.WorkoutSegment
+	}{
+		{
+			name:     "steady state",
+			steps:    `<SteadyState Duration="300" Power="0.75" Cadence="90"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRelativeSegment(5*time.Minute, 0.75, 0.75).WithCadence(90, 90)},
+		},
+		{
+			name:     "steady state with a power range",
+			steps:    `<SteadyState Duration="60" PowerLow="0.7" PowerHigh="0.8"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRelativeSegment(time.Minute, 0.75, 0.75)},
+		},
+		{
+			name:     "warmup",
+			steps:    `<Warmup Duration="600" PowerLow="0.5" PowerHigh="0.75" CadenceLow="85" CadenceHigh="95"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRelativeSegment(10*time.Minute, 0.5, 0.75).WithCadence(85, 95)},
+		},
+		{
+			name:     "ramp",
+			steps:    `<Ramp Duration="120" PowerLow="1.0" PowerHigh="1.2"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRelativeSegment(2*time.Minute, 1.0, 1.2)},
+		},
+		{
+			name:  "intervals",
+			steps: `<IntervalsT Repeat="3" OnDuration="30" OffDuration="60" OnPower="1.2" OffPower="0.5" Cadence="100" CadenceResting="85"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRepeat(3,
+				workout.NewRelativeSegment(30*time.Second, 1.2, 1.2).WithCadence(100, 100),
+				workout.NewRelativeSegment(time.Minute, 0.5, 0.5).WithCadence(85, 85),
+			)},
+		},
+		{
+			name:     "free ride",
+			steps:    `<FreeRide Duration="180"/>`,
+			expected: []workout.WorkoutSegment{workout.NewFreeRideSegment(3 * time.Minute)},
+		},
+		{
+			name:     "text events are skipped",
+			steps:    `<textevent timeoffset="0" message="go"/><SteadyState Duration="60" Power="0.6"/>`,
+			expected: []workout.WorkoutSegment{workout.NewRelativeSegment(time.Minute, 0.6, 0.6)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := workout.FromZwo(strings.NewReader(zwo(tt.steps)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if w.Name != "Test" {
+				t.Errorf("expected the name Test, got %q", w.Name)
+			}
+			if !reflect.DeepEqual(w.Segments, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, w.Segments)
+			}
+		})
+	}
+}
+
+func TestFromZwoInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected error
+	}{
+		{
+			name:     "missing workout",
+			file:     `<workout_file><name>Test</name></workout_file>`,
+			expected: workout.ErrNoSteps,
+		},
+		{
+			name:     "unknown step",
+			file:     zwo(`<Sprint Duration="10"/>`),
+			expected: workout.ErrMalformedStep,
+		},
+		{
+			name:     "negative duration",
+			file:     zwo(`<SteadyState Duration="-60" Power="0.75"/>`),
+			expected: workout.ErrInvalidDuration,
+		},
+		{
+			name:     "absurd power",
+			file:     zwo(`<SteadyState Duration="60" Power="12"/>`),
+			expected: workout.ErrAbsurdPower,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := workout.FromZwo(strings.NewReader(tt.file))
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// attributes that are not numbers can't be parsed at all
+	for _, file := range []string{
+		zwo(`<SteadyState Duration="long" Power="0.75"/>`),
+		zwo(`<IntervalsT Repeat="x" OnDuration="30" OffDuration="30" OnPower="1" OffPower="0.5"/>`),
+		`<workout_file><name>Test</name><workout>`,
+	} {
+		if _, err := workout.FromZwo(strings.NewReader(file)); err == nil {
+			t.Errorf("expected %s not to parse", file)
+		}
+	}
+}
+
+func TestFromZwoFTP(t *testing.T) {
+	w, err := workout.FromZwo(strings.NewReader(zwo(`<Warmup Duration="60" PowerLow="0.5" PowerHigh="1.0"/>`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the targets follow the FTP of the rider
+	for _, tt := range []struct {
+		ftp        int
+		start, end workout.Watts
+	}{
+		{ftp: 200, start: 100, end: 200},
+		{ftp: 300, start: 150, end: 300},
+	} {
+		s := w.Segments[0]
+		if s.StartWatts(tt.ftp) != tt.start || s.EndWatts(tt.ftp) != tt.end {
+			t.Errorf("expected %d-%dW at an FTP of %d, got %d-%dW", tt.start, tt.end, tt.ftp, s.StartWatts(tt.ftp), s.EndWatts(tt.ftp))
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
 	"path"
+	"slices"
+	"strings"
 	"time"
 
 	"overlay/game"
@@ -20,38 +27,235 @@ var mock = flag.Bool(
 	false,
 	"Sets up a mock trainer instead of connecting to a real trainer",
 )
+
+var mockProfile = flag.String(
+	"mock-profile",
+	"",
+	"path to a JSON profile of how the rider on the mock trainer rides, implies -mock",
+)
+
+var mockFollow = flag.Bool("mock-follow", false, "lets the mock trainer follow the target power of the workout")
+
+var mockNoise = flag.Float64("mock-noise", 0, "standard deviation of the power of the mock trainer in watts")
+
+var replay = flag.String(
+	"replay",
+	"",
+	"replays a recording of a ride instead of connecting to a real trainer, see -record",
+)
+
+var replaySpeed = flag.Float64("replay-speed", 1, "how many times faster than recorded a ride is replayed")
+
+var record = flag.String("record", "", "records all bluetooth traffic of the ride to a file to replay it later")
+
+var trainerDevice = flag.String(
+	"trainer",
+	"",
+	"address or part of the name of the trainer to connect to, see the scan command",
+)
+
+var hrDevice = flag.String(
+	"hr",
+	"",
+	"address or part of the name of the heart rate strap to connect to, see the scan command",
+)
+
+var scanTimeout = flag.Duration("scan-timeout", 10*time.Second, "how long to look for bluetooth devices")
+
 var headless = flag.Bool("headless", false, "Sets up the game in headless mode for testing")
 
 var selectedWorkout = flag.String("workout", "", "workout to start")
 
-func newDevice() (*bluetooth.Device, error) {
-	if *mock {
+var workoutFile = flag.String(
+	"workout-file",
+	"",
+	"path to a workout file to start, the format is picked by its extension (.zwo, .txt)",
+)
+
+var workoutID = flag.Int64("workout-id", 0, "ID of a workout in the workout library to start")
+
+var tags = flag.String("tags", "", "comma separated tags for the saved workout")
+
+var powerMatch = flag.Bool(
+	"power-match",
+	false,
+	"corrects the target power of the trainer with the readings of a power meter",
+)
+
+var ftp = flag.Int(
+	"ftp",
+	0,
+	"FTP of the rider in watts, overrides the FTP of the workout. Relative targets are resolved against it",
+)
+
+// newDevice connects to the devices used last time first
+// and remembers the connected ones for the next ride.
+func newDevice(deviceRepo *repo.DeviceRepo) (*bluetooth.Device, error) {
+	if *mock || *mockProfile != "" {
 		return newMockDevice()
 	}
+	if *replay != "" {
+		return newReplayDevice()
+	}
+
+	opts := []func(*bluetooth.ConnectOpts){
+		bluetooth.WithScanTimeout(*scanTimeout),
+		bluetooth.WithDevice(bluetooth.RoleTrainer, *trainerDevice),
+		bluetooth.WithDevice(bluetooth.RoleHrMonitor, *hrDevice),
+		bluetooth.WithPowerMatch(*powerMatch),
+	}
+
+	if *record != "" {
+		// the file stays open until the program ends
+		f, err := os.Create(*record)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info("Recording bluetooth traffic to " + *record)
+		opts = append(opts, bluetooth.WithRecorder(bluetooth.NewRecorder(f)))
+	}
+
+	known, err := deviceRepo.GetAll()
+	if err != nil {
+		slog.Error(err.Error())
+	}
 
-	return bluetooth.Connect()
+	for _, dev := range known {
+		role, ok := bluetooth.ParseRole(dev.Role)
+		if !ok {
+			continue
+		}
+
+		p := bluetooth.Peripheral{Name: dev.Name, Address: dev.Address}
+		opts = append(opts, bluetooth.WithKnownDevice(role, p))
+	}
+
+	trainer, err := bluetooth.Connect(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for role, p := range trainer.Peripherals {
+		if err := deviceRepo.Save(role.Key(), p.Address, p.Name); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+
+	// a device that stays unreachable was probably replaced
+	for role, p := range trainer.Unreachable {
+		forgotten, err := deviceRepo.MarkUnreachable(role.Key())
+		if err != nil {
+			slog.Error(err.Error())
+			continue
+		}
+		if forgotten {
+			slog.Info(fmt.Sprintf("Forgetting %s %s, it was unreachable %d times in a row", role, p.Name, repo.MaxFailures))
+		}
+	}
+
+	return trainer, nil
 }
 
+// newMockDevice rides the mock profile, the flags override the profile
 func newMockDevice() (*bluetooth.Device, error) {
-	trainer := bluetooth.NewMockDevice()
+	profile := bluetooth.DefaultMockProfile
+	if *mockProfile != "" {
+		f, err := os.Open(*mockProfile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		profile, err = bluetooth.LoadMockProfile(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "mock-follow":
+			profile.FollowTarget = *mockFollow
+		case "mock-noise":
+			profile.Noise = *mockNoise
+		}
+	})
+
+	trainer, err := bluetooth.NewProfileDevice(profile)
+	if err != nil {
+		return nil, err
+	}
 	return &trainer, nil
 }
 
-func newTraining(gpxRepo *repo.GPXRepo) {
-	flag.Parse()
-
-	trainer, err := newDevice()
+func newReplayDevice() (*bluetooth.Device, error) {
+	f, err := os.Open(*replay)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	defer f.Close()
+
+	slog.Info("Replaying " + *replay)
+	return bluetooth.Replay(f, *replaySpeed)
+}
+
+// dbPath returns the path of the database shared by all repos
+func dbPath() string {
+	p, _ := os.Getwd()
+	return path.Join(p, "../", "db")
+}
+
+// loadWorkout returns the workout selected on the command line,
+// a random workout is returned when none was given.
+func loadWorkout(workouts *repo.WorkoutRepo) (*workout.Workout, error) {
+	if *workoutID > 0 {
+		return withFTP(workouts.GetWorkout(*workoutID))
+	}
+
+	if *workoutFile != "" {
+		slog.Info("Loading workout file " + *workoutFile)
+		return withFTP(workout.FromFile(*workoutFile))
 	}
 
-	training := workout.NewRandom()
-	if selectedWorkout != nil && *selectedWorkout != "" {
+	if *selectedWorkout != "" {
 		slog.Info(*selectedWorkout)
-		training, err = workout.FromString(*selectedWorkout)
-		if err != nil {
-			panic(err)
+		return withFTP(workout.FromString(*selectedWorkout))
+	}
+
+	return withFTP(workout.NewRandom(), nil)
+}
+
+// withFTP sets the FTP of the rider on the workout when it is given
+// and checks if the workout can be ridden.
+func withFTP(training *workout.Workout, err error) (*workout.Workout, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if *ftp > 0 {
+		training.FTP = *ftp
+	}
+
+	if err := workout.Validate(*training); err != nil {
+		if errors.Is(err, workout.ErrMissingFTP) {
+			return nil, fmt.Errorf("%w, set it with the -ftp flag", err)
 		}
+		return nil, err
+	}
+
+	return training, nil
+}
+
+func newTraining(gpxRepo *repo.GPXRepo, workoutRepo *repo.WorkoutRepo, deviceRepo *repo.DeviceRepo) {
+	training, err := loadWorkout(workoutRepo)
+	if err != nil {
+		panic(err)
+	}
+
+	trainer, err := newDevice(deviceRepo)
+	if err != nil {
+		panic(err)
 	}
 
 	gpxFile := gpx.New(training.Name)
@@ -59,22 +263,29 @@ func newTraining(gpxRepo *repo.GPXRepo) {
 	// listen for data of the trainer
 	trainer.Listen()
 
-	// use the data to build a gpx file
+	// use the data to build a gpx file until the game ends
+	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
-		gpxFile.Build(trainer)
+		gpxFile.Build(ctx, trainer)
 	}()
+	// the game shows the lowest level, the ride stores the levels at its end
+	batteries := bluetooth.WatchBatteryLevels(ctx, trainer)
 
 	// use the data to run the game
 	// the game needs to run in the main thread according
 	// to the ebiten spec
-	opts := game.NewOpts(game.WithHeadless(*headless), game.WithTickDuration(time.Second))
+	opts := game.NewOpts(
+		game.WithHeadless(*headless),
+		game.WithTickDuration(time.Second),
+		game.WithBatteryLevels(batteries),
+	)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		for range c {
 			slog.Info("Program interrupted, writing to file...")
-			_, err = gpxRepo.Create(training.Name, gpxFile)
+			_, err = gpxRepo.Create(training.Name, gpxFile, rideDevices(trainer, batteries)...)
 			if err != nil {
 				slog.Error(err.Error())
 			}
@@ -84,19 +295,140 @@ func newTraining(gpxRepo *repo.GPXRepo) {
 	}()
 
 	game.Run(training, trainer, opts)
+	cancel()
 
 	slog.Info("Game ended")
-	_, err = gpxRepo.Create(training.Name, gpxFile)
+	_, err = gpxRepo.Create(training.Name, gpxFile, rideDevices(trainer, batteries)...)
 	if err != nil {
 		slog.Error(err.Error())
 	}
+
+	if *workoutID > 0 {
+		err = workoutRepo.MarkRidden(*workoutID, time.Now())
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	}
 }
 
-func main() {
-	p, _ := os.Getwd()
-	repo, err := repo.NewGPXRepo(path.Join(p, "../", "db"))
+// rideDevices are the connected devices as they are stored with a ride,
+// with the battery levels at the end of the ride.
+func rideDevices(trainer *bluetooth.Device, batteries *bluetooth.BatteryLevels) []repo.RideDevice {
+	var devices []repo.RideDevice
+	for _, role := range slices.Sorted(maps.Keys(trainer.Peripherals)) {
+		p, info := trainer.Peripherals[role], trainer.Info[role]
+		devices = append(devices, repo.RideDevice{
+			Role:         role.String(),
+			Name:         p.Name,
+			Address:      p.Address,
+			Manufacturer: info.Manufacturer,
+			Model:        info.Model,
+			Serial:       info.Serial,
+			Firmware:     info.Firmware,
+			Battery:      batteries.Level(role),
+		})
+	}
+	return devices
+}
+
+// save stores the selected workout in the workout library
+// and prints the ID to start it with later on.
+func save() error {
+	workouts, err := repo.NewWorkoutRepo(dbPath())
 	if err != nil {
-		panic(err)
+		return err
+	}
+	defer workouts.Close()
+
+	training, err := loadWorkout(workouts)
+	if err != nil {
+		return err
+	}
+
+	var workoutTags []string
+	if *tags != "" {
+		workoutTags = strings.Split(*tags, ",")
+	}
+
+	saved, err := workouts.Create(*training, workoutTags...)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(saved)
+	}
+
+	fmt.Println(saved.ID)
+	return nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  ride       starts the workout on the trainer (default)")
+	fmt.Fprintln(out, "  summary    prints the planned metrics of the workout")
+	fmt.Fprintln(out, "  save       stores the workout in the workout library and prints its ID")
+	fmt.Fprintln(out, "  scan       lists the nearby bluetooth devices")
+	fmt.Fprintln(out, "  calibrate  zeroes the power meter and spins down the trainer")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+
+	// the command is the first argument that is not a flag
+	cmd, args := "ride", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
+
+	switch cmd {
+	case "ride":
+		gpxRepo, err := repo.NewGPXRepo(dbPath())
+		if err != nil {
+			panic(err)
+		}
+		defer gpxRepo.Close()
+
+		workoutRepo, err := repo.NewWorkoutRepo(dbPath())
+		if err != nil {
+			panic(err)
+		}
+		defer workoutRepo.Close()
+
+		deviceRepo, err := repo.NewDeviceRepo(dbPath())
+		if err != nil {
+			panic(err)
+		}
+		defer deviceRepo.Close()
+		newTraining(gpxRepo, workoutRepo, deviceRepo)
+	case "summary":
+		if err := summary(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	case "save":
+		if err := save(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	case "scan":
+		if err := scan(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	case "calibrate":
+		if err := calibrate(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(flag.CommandLine.Output(), "unknown command %q\n\n", cmd)
+		flag.Usage()
+		os.Exit(2)
 	}
-	newTraining(repo)
 }
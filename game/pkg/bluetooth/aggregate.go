@@ -0,0 +1,107 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// aggregate is a readwriter that combines the readings of the same
+// metric from multiple devices. The sources are ordered by priority,
+// a reading is only passed on while the sources before it are stale.
+type aggregate struct {
+	sources    []readwriter
+	staleAfter time.Duration
+	now        func() time.Time
+
+	mu   sync.Mutex
+	last []time.Time
+	// available tells if any source accepted a listener
+	available bool
+
+	listeners
+}
+
+func newAggregate(staleAfter time.Duration, sources ...readwriter) *aggregate {
+	a := &aggregate{
+		sources:    sources,
+		staleAfter: staleAfter,
+		now:        time.Now,
+		last:       make([]time.Time, len(sources)),
+	}
+
+	for i, s := range sources {
+		sub, ok := s.Subscribe(context.Background(), 1)
+		if !ok {
+			continue
+		}
+
+		a.available = true
+		go func() {
+			for v := range sub.C {
+				a.receive(i, v)
+			}
+		}()
+	}
+
+	return a
+}
+
+// receive passes on a sample of source i as is, so
+// the listeners know which source it came from.
+func (a *aggregate) receive(i int, s Sample) {
+	a.mu.Lock()
+	now := a.now()
+	a.last[i] = now
+	for _, last := range a.last[:i] {
+		if now.Sub(last) < a.staleAfter {
+			a.mu.Unlock()
+			return
+		}
+	}
+	a.mu.Unlock()
+
+	a.publish(s)
+}
+
+func (a *aggregate) AddListener(c chan int) bool {
+	if !a.available {
+		return false
+	}
+	return a.listeners.AddListener(c)
+}
+
+func (a *aggregate) Subscribe(ctx context.Context, size int) (*Subscription[Sample], bool) {
+	if !a.available {
+		return nil, false
+	}
+	return a.listeners.Subscribe(ctx, size)
+}
+
+// ContinuousRead reads all sources, it only fails when none of them can be read
+func (a *aggregate) ContinuousRead() error {
+	var errs []error
+	for _, s := range a.sources {
+		if err := s.ContinuousRead(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == len(a.sources) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// Write writes to the first source that is not read only,
+// e.g. the target power goes to the trainer and not the power meter.
+func (a *aggregate) Write(v int) (int, error) {
+	for _, s := range a.sources {
+		n, err := s.Write(v)
+		if !errors.Is(err, errReadOnly) {
+			return n, err
+		}
+	}
+	return 0, errReadOnly
+}
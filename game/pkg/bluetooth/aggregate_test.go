@@ -0,0 +1,119 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeSource is a readwriter that passes on the values written to it
+type fakeSource struct {
+	listeners
+	readOnly bool
+	written  []int
+}
+
+func (f *fakeSource) ContinuousRead() error {
+	return nil
+}
+
+func (f *fakeSource) Write(v int) (int, error) {
+	if f.readOnly {
+		return 0, errReadOnly
+	}
+
+	f.written = append(f.written, v)
+	return v, nil
+}
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func newTestAggregate(sources ...readwriter) (*aggregate, *fakeClock, chan int) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	a := newAggregate(3*time.Second, sources...)
+	a.now = clock.now
+
+	c := make(chan int, 10)
+	a.AddListener(c)
+	return a, clock, c
+}
+
+func TestAggregatePriority(t *testing.T) {
+	meter, trainer := &fakeSource{}, &fakeSource{}
+	a, clock, c := newTestAggregate(meter, trainer)
+
+	// only the trainer has readings
+	a.receive(1, Sample{Value: 190})
+	if v := <-c; v != 190 {
+		t.Errorf("expected the trainer reading 190, got %d", v)
+	}
+
+	// the power meter takes over
+	a.receive(0, Sample{Value: 200})
+	a.receive(1, Sample{Value: 190})
+	clock.t = clock.t.Add(time.Second)
+	a.receive(1, Sample{Value: 191})
+	if v := <-c; v != 200 {
+		t.Errorf("expected the power meter reading 200, got %d", v)
+	}
+	if len(c) != 0 {
+		t.Errorf("trainer readings should be dropped while the power meter is fresh, got %d", <-c)
+	}
+
+	// the power meter stops, the trainer is used again
+	clock.t = clock.t.Add(3 * time.Second)
+	a.receive(1, Sample{Value: 192})
+	if v := <-c; v != 192 {
+		t.Errorf("expected the trainer reading 192 after the power meter went stale, got %d", v)
+	}
+}
+
+func TestAggregateListensToSources(t *testing.T) {
+	meter, trainer := &fakeSource{}, &fakeSource{}
+	_, _, c := newTestAggregate(meter, trainer)
+
+	meter.WriteValue(200)
+	if v := <-c; v != 200 {
+		t.Errorf("expected 200, got %d", v)
+	}
+}
+
+func TestAggregateKeepsSource(t *testing.T) {
+	meter, trainer := &fakeSource{}, &fakeSource{}
+	meter.label(UnitWatt, "power meter")
+	a := newAggregate(3*time.Second, meter, trainer)
+
+	sub, _ := a.Subscribe(context.Background(), 1)
+	meter.WriteValue(200)
+
+	sample := <-sub.C
+	if sample.Source != "power meter" || sample.Unit != UnitWatt || sample.Value != 200 {
+		t.Errorf("expected 200W of the power meter, got %+v", sample)
+	}
+}
+
+func TestAggregateWrite(t *testing.T) {
+	meter, trainer := &fakeSource{readOnly: true}, &fakeSource{}
+	a, _, _ := newTestAggregate(meter, trainer)
+
+	if _, err := a.Write(250); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trainer.written) != 1 || trainer.written[0] != 250 {
+		t.Errorf("expected the target to be written to the trainer, got %v", trainer.written)
+	}
+
+	a, _, _ = newTestAggregate(&fakeSource{readOnly: true})
+	if _, err := a.Write(250); !errors.Is(err, errReadOnly) {
+		t.Errorf("expected read only, got %v", err)
+	}
+}
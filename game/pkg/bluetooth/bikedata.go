@@ -0,0 +1,235 @@
+package bluetooth
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+var errReadOnly = errors.New("characteristic is read only")
+
+// flags of the indoor bike data characteristic, see section 4.9 of the FTMS spec.
+// Every flag tells if a field is present in the notification.
+const (
+	// moreData is inverted, instantaneous speed is present when it is not set
+	ibdMoreData uint16 = 1 << iota
+	ibdAverageSpeed
+	ibdInstantaneousCadence
+	ibdAverageCadence
+	ibdTotalDistance
+	ibdResistanceLevel
+	ibdInstantaneousPower
+	ibdAveragePower
+	ibdExpendedEnergy
+	ibdHeartRate
+	ibdMetabolicEquivalent
+	ibdElapsedTime
+	ibdRemainingTime
+)
+
+// indoorBikeData holds a decoded indoor bike data notification.
+// Only the fields with their flag set in flags are filled in.
+type indoorBikeData struct {
+	flags uint16
+
+	Speed          int // in m/h
+	AverageSpeed   int // in m/h
+	Cadence        int // in rpm
+	AverageCadence int // in rpm
+	Distance       int // in m
+	Resistance     int
+	Power          int // in W
+	AveragePower   int // in W
+
+	TotalEnergy     int // in kcal
+	EnergyPerHour   int // in kcal
+	EnergyPerMinute int // in kcal
+
+	Hr                  int // in bpm
+	MetabolicEquivalent float64
+	ElapsedTime         int // in s
+	RemainingTime       int // in s
+}
+
+// hasSpeed reports whether the instantaneous speed is present
+func (d indoorBikeData) hasSpeed() bool {
+	return d.flags&ibdMoreData == 0
+}
+
+func (d indoorBikeData) has(flag uint16) bool {
+	return d.flags&flag != 0
+}
+
+// decodeIndoorBikeData decodes the fields that are present according to the flags
+func decodeIndoorBikeData(buf []byte) (indoorBikeData, error) {
+	r := byteReader{buf: buf}
+	d := indoorBikeData{flags: r.uint16()}
+
+	// the speed comes from the km/h with a resolution of 0.01
+	if d.hasSpeed() {
+		d.Speed = int(r.uint16()) * 10
+	}
+	if d.has(ibdAverageSpeed) {
+		d.AverageSpeed = int(r.uint16()) * 10
+	}
+	// cadence has a resolution of 0.5 rpm
+	if d.has(ibdInstantaneousCadence) {
+		d.Cadence = int(r.uint16()) / 2
+	}
+	if d.has(ibdAverageCadence) {
+		d.AverageCadence = int(r.uint16()) / 2
+	}
+	if d.has(ibdTotalDistance) {
+		d.Distance = int(r.uint24())
+	}
+	if d.has(ibdResistanceLevel) {
+		d.Resistance = int(r.int16())
+	}
+	if d.has(ibdInstantaneousPower) {
+		d.Power = int(r.int16())
+	}
+	if d.has(ibdAveragePower) {
+		d.AveragePower = int(r.int16())
+	}
+	if d.has(ibdExpendedEnergy) {
+		d.TotalEnergy = int(r.uint16())
+		d.EnergyPerHour = int(r.uint16())
+		d.EnergyPerMinute = int(r.uint8())
+	}
+	if d.has(ibdHeartRate) {
+		d.Hr = int(r.uint8())
+	}
+	if d.has(ibdMetabolicEquivalent) {
+		d.MetabolicEquivalent = float64(r.uint8()) / 10
+	}
+	if d.has(ibdElapsedTime) {
+		d.ElapsedTime = int(r.uint16())
+	}
+	if d.has(ibdRemainingTime) {
+		d.RemainingTime = int(r.uint16())
+	}
+
+	if r.err != nil {
+		return indoorBikeData{}, fmt.Errorf("could not decode indoor bike data: %w", r.err)
+	}
+	return d, nil
+}
+
+// byteReader reads little-endian values from a notification.
+// Reading past the end sets err and returns 0.
+type byteReader struct {
+	buf []byte
+	err error
+}
+
+var errShortBuffer = errors.New("notification is too short")
+
+func (r *byteReader) next(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		r.err = errShortBuffer
+		return make([]byte, n)
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *byteReader) uint8() uint8 {
+	return r.next(1)[0]
+}
+
+func (r *byteReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(r.next(2))
+}
+
+func (r *byteReader) int16() int16 {
+	return int16(r.uint16())
+}
+
+func (r *byteReader) uint24() uint32 {
+	b := r.next(3)
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// indoorBikeDataCharacteristic subscribes to the indoor bike data
+// of a trainer and splits it up in a readwriter per field.
+type indoorBikeDataCharacteristic struct {
+	char bluetoothcharacteristic
+
+	once sync.Once
+	err  error
+
+	speed       field
+	cadence     field
+	resistance  field
+	hr          field
+	elapsedTime field
+}
+
+func newIndoorBikeData(char bluetoothcharacteristic) *indoorBikeDataCharacteristic {
+	b := &indoorBikeDataCharacteristic{char: char}
+	for _, f := range b.fields() {
+		f.source = b
+	}
+	return b
+}
+
+func (b *indoorBikeDataCharacteristic) fields() []*field {
+	return []*field{&b.speed, &b.cadence, &b.resistance, &b.hr, &b.elapsedTime}
+}
+
+// enable subscribes to the notifications, this only
+// happens once for all fields together.
+func (b *indoorBikeDataCharacteristic) enable() error {
+	b.once.Do(func() {
+		b.err = b.char.EnableNotifications(b.notify)
+	})
+	return b.err
+}
+
+func (b *indoorBikeDataCharacteristic) notify(buf []byte) {
+	data, err := decodeIndoorBikeData(buf)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	if data.hasSpeed() {
+		b.speed.WriteValue(data.Speed)
+	}
+	if data.has(ibdInstantaneousCadence) {
+		b.cadence.WriteValue(data.Cadence)
+	}
+	if data.has(ibdResistanceLevel) {
+		b.resistance.WriteValue(data.Resistance)
+	}
+	if data.has(ibdHeartRate) {
+		b.hr.WriteValue(data.Hr)
+	}
+	if data.has(ibdElapsedTime) {
+		b.elapsedTime.WriteValue(data.ElapsedTime)
+	}
+}
+
+// enabler enables the notifications of a characteristic
+type enabler interface {
+	enable() error
+}
+
+// field is a readwriter for a single value of a characteristic
+// that notifies multiple values at once, e.g. the indoor bike data.
+type field struct {
+	listeners
+	source enabler
+}
+
+func (f *field) ContinuousRead() error {
+	return f.source.enable()
+}
+
+func (f *field) Write(int) (int, error) {
+	return 0, errReadOnly
+}
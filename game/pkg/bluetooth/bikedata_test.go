@@ -0,0 +1,101 @@
+package bluetooth
+
+import "testing"
+
+func TestDecodeIndoorBikeData(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      []byte
+		expected indoorBikeData
+	}{
+		{
+			name: "speed cadence and power",
+			// flags 0x0044, 36 km/h, 90 rpm, 200 W
+			buf: []byte{0x44, 0x00, 0x10, 0x0e, 0xb4, 0x00, 0xc8, 0x00},
+			expected: indoorBikeData{
+				flags:   0x0044,
+				Speed:   36000,
+				Cadence: 90,
+				Power:   200,
+			},
+		},
+		{
+			name: "more data without speed",
+			// flags 0x0041, 250 W
+			buf: []byte{0x41, 0x00, 0xfa, 0x00},
+			expected: indoorBikeData{
+				flags: 0x0041,
+				Power: 250,
+			},
+		},
+		{
+			name: "distance resistance heart rate and elapsed time",
+			// flags 0x0a74, 28.5 km/h, 85.5 rpm, 70000 m, resistance 12, 180 W, 142 bpm, 3600 s
+			buf: []byte{
+				0x74, 0x0a,
+				0x22, 0x0b,
+				0xab, 0x00,
+				0x70, 0x11, 0x01,
+				0x0c, 0x00,
+				0xb4, 0x00,
+				0x8e,
+				0x10, 0x0e,
+			},
+			expected: indoorBikeData{
+				flags:       0x0a74,
+				Speed:       28500,
+				Cadence:     85,
+				Distance:    70000,
+				Resistance:  12,
+				Power:       180,
+				Hr:          142,
+				ElapsedTime: 3600,
+			},
+		},
+		{
+			name: "energy and averages",
+			// flags 0x018a: average speed, average cadence, average power, energy
+			buf: []byte{
+				0x8a, 0x01,
+				0xe8, 0x03,
+				0xd0, 0x07,
+				0xa0, 0x00,
+				0x96, 0x00,
+				0x2c, 0x01,
+				0x58, 0x02,
+				0x0a,
+			},
+			expected: indoorBikeData{
+				flags:           0x018a,
+				Speed:           10000,
+				AverageSpeed:    20000,
+				AverageCadence:  80,
+				AveragePower:    150,
+				TotalEnergy:     300,
+				EnergyPerHour:   600,
+				EnergyPerMinute: 10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := decodeIndoorBikeData(tt.buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != tt.expected {
+				t.Errorf("Should be %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeIndoorBikeDataTooShort(t *testing.T) {
+	// cadence and power flags are set, but the power is missing
+	_, err := decodeIndoorBikeData([]byte{0x44, 0x00, 0x10, 0x0e, 0xb4, 0x00})
+	if err == nil {
+		t.Error("Should fail on a truncated notification")
+	}
+}
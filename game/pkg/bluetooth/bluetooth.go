@@ -1,193 +1,381 @@
 package bluetooth
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"sync"
+	"slices"
 	"time"
 
 	"tinygo.org/x/bluetooth"
 )
 
-//go:generate go tool mockgen -source=bluetooth.go -destination=mocks/bluetooth.go -package=bluetooth_mocks
+//go:generate go tool mockgen -source=bluetooth.go -destination=mocks_test.go -package=bluetooth -self_package=overlay/pkg/bluetooth
 type bluetoothadapter interface {
 	Scan(callback func(*bluetooth.Adapter, bluetooth.ScanResult)) (err error)
 	StopScan() error
-	Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (bluetooth.Device, error)
+	Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (bluetootdevice, error)
 }
 
-//go:generate go tool mockgen -source=bluetooth.go -destination=mocks/bluetooth.go -package=bluetooth_mocks
+//go:generate go tool mockgen -source=bluetooth.go -destination=mocks_test.go -package=bluetooth -self_package=overlay/pkg/bluetooth
 type bluetootdevice interface {
-	DiscoverCharacteristics(uuids []bluetooth.UUID) ([]bluetooth.DeviceCharacteristic, error)
-	DiscoverServices(uuids []bluetooth.UUID) ([]bluetooth.DeviceService, error)
+	DiscoverServices(uuids []bluetooth.UUID) ([]bluetoothservice, error)
 }
 
-func Connect() (*Device, error) {
-	adapter := bluetooth.DefaultAdapter
-	err := adapter.Enable()
+// bluetoothservice is a GATT service of a connected device
+type bluetoothservice interface {
+	UUID() bluetooth.UUID
+	DiscoverCharacteristics(uuids []bluetooth.UUID) ([]bluetoothcharacteristic, error)
+}
+
+// bluetoothcharacteristic is a GATT characteristic of a connected device
+type bluetoothcharacteristic interface {
+	UUID() bluetooth.UUID
+	EnableNotifications(callback func(buf []byte)) error
+	Write(p []byte) (n int, err error)
+	Read(data []byte) (int, error)
+}
+
+// Connect scans for a trainer and the optional power meter, heart rate
+// strap and speed/cadence sensor. The readings of all of them are
+// combined into a single device, see ConnectOpts for which one wins.
+//
+// Known devices are connected directly. When the trainer is connected
+// that way, the missing sensors that were known or chosen are only
+// looked for during ScanGrace, the trainer is all that is needed to ride.
+func Connect(optsArgs ...func(*ConnectOpts)) (*Device, error) {
+	opts := NewConnectOpts(optsArgs...)
+
+	err := bluetooth.DefaultAdapter.Enable()
 	if err != nil {
 		panic(err)
 	}
 
-	slog.Info("Finding trainer...")
-	readPowerChar, writePowerChar, err := discover(adapter)
-	if err != nil {
-		return nil, err
+	return connect(gattAdapter{bluetooth.DefaultAdapter}, opts)
+}
+
+// connect connects to the devices of a session with any adapter
+func connect(adapter bluetoothadapter, opts ConnectOpts) (*Device, error) {
+	if opts.Recorder != nil {
+		adapter = recordingAdapter{adapter, opts.Recorder}
 	}
 
-	err = writePowerChar.EnableNotifications(func(buf []byte) {
-		println("Notification received:", buf)
-	})
+	devices := map[Role]Device{}
+	peripherals := map[Role]Peripheral{}
 
-	if err != nil {
-		slog.Error(err.Error())
+	unreachable := map[Role]Peripheral{}
+	for role, p := range opts.Known {
+		if selector, ok := opts.Devices[role]; ok && !selected(selector, p.Name, p.Address) {
+			continue
+		}
+
+		d, err := connectAddress(adapter, role, p.Address)
+		if err != nil {
+			slog.Info(fmt.Sprintf("Could not reach %s %s: %s", role, p.Name, err))
+			unreachable[role] = p
+			continue
+		}
+
+		slog.Info(fmt.Sprintf("Connected %s %s", role, p.Name))
+		devices[role], peripherals[role] = d, p
 	}
 
-	powerChar := powerCharacteristic{
-		readPwr:  readPowerChar,
-		writePwr: writePowerChar,
+	scanOpts := opts
+	var missing []Role
+	for _, role := range roles {
+		if _, ok := devices[role]; !ok {
+			missing = append(missing, role)
+		}
+	}
+	if _, ok := devices[RoleTrainer]; ok {
+		// the trainer was there right away, don't keep the rider waiting for the others
+		missing = slices.DeleteFunc(missing, func(role Role) bool { return !opts.required(role) })
+		scanOpts.ScanTimeout = min(opts.ScanTimeout, opts.ScanGrace)
 	}
 
-	err = powerChar.requestControl()
-	if err != nil {
-		slog.Error(err.Error())
+	var trainerErr error
+	if len(missing) > 0 {
+		slog.Info("Finding devices...")
+		for role, result := range scanRoles(adapter, scanOpts, missing) {
+			d, err := connectRole(adapter, role, result.Address)
+			if err != nil {
+				if role == RoleTrainer {
+					trainerErr = err
+				}
+
+				slog.Info(fmt.Sprintf("Skipping %s: %s", role, err))
+				continue
+			}
+
+			slog.Info(fmt.Sprintf("Connected %s %s", role, result.LocalName()))
+			devices[role], peripherals[role] = d, newPeripheral(result)
+			delete(unreachable, role)
+		}
 	}
 
-	trainer := NewDevice(WithPower(&powerChar))
+	if _, ok := devices[RoleTrainer]; !ok {
+		if trainerErr != nil {
+			return nil, trainerErr
+		}
+		if selector, ok := opts.Devices[RoleTrainer]; ok {
+			return nil, fmt.Errorf("Bluetooth deadline exceeded, trainer %q not found...", selector)
+		}
+		return nil, fmt.Errorf("Bluetooth deadline exceeded, no trainer found...")
+	}
+
+	// the trainer is the one that is needed to ride, reconnect when it drops
+	if !opts.unwatched {
+		address := peripherals[RoleTrainer].Address
+		watched, connection := watch(devices[RoleTrainer], func() (Device, error) {
+			return connectAddress(adapter, RoleTrainer, address)
+		})
+		devices[RoleTrainer] = watched
+		go connection.run(nil)
+	}
+
+	trainer := combine(devices, opts)
+	trainer.Peripherals = peripherals
+	trainer.Unreachable = unreachable
 	return &trainer, nil
 }
 
-// discover checks every available device
-// having the FTMS service and cyling power service.
-//
-//	It returns two bluetooth characteristics.
-//
-// the first char can be used to get power notifications and
-// the second char can be used to set power on the device
-func discover(
-	adapter bluetoothadapter,
-) (*bluetooth.DeviceCharacteristic, *bluetooth.DeviceCharacteristic, error) {
-	var powChar *bluetooth.DeviceCharacteristic
-	var ftmsChar *bluetooth.DeviceCharacteristic
-	done := make(chan struct{})
-
-	scanned := map[string]bool{}
-	var wg sync.WaitGroup
+// connectAddress connects to a device without scanning for it first
+func connectAddress(adapter bluetoothadapter, role Role, address string) (Device, error) {
+	var addr bluetooth.Address
+	addr.Set(address)
+	return connectRole(adapter, role, addr)
+}
 
-	wg.Add(1)
-	go func() {
-		slog.Info("Scanning bluetooth devices...")
-		err := adapter.Scan(func(adapter *bluetooth.Adapter, device bluetooth.ScanResult) {
-			if scanned[device.Address.String()] {
-				return
-			}
+// connectRole connects to a device and subscribes to the
+// characteristics of its role, its samples have the role as source.
+func connectRole(adapter bluetoothadapter, role Role, address bluetooth.Address) (Device, error) {
+	d, err := connectDevice(adapter, role, address)
+	if err != nil {
+		return Device{}, err
+	}
 
-			scanned[device.Address.String()] = true
+	d.label(role.String())
+	return d, nil
+}
 
-			slog.Info("Found device with uuid: " + device.Address.String())
+func connectDevice(adapter bluetoothadapter, role Role, address bluetooth.Address) (Device, error) {
+	device, err := adapter.Connect(
+		address,
+		bluetooth.ConnectionParams{
+			ConnectionTimeout: bluetooth.NewDuration(2 * time.Second),
+		},
+	)
+	if err != nil {
+		return Device{}, err
+	}
 
-			go func() {
-				pChar, ftmsControlPointChar, err := verifyResult(adapter, device)
-				if err != nil {
-					slog.Info(err.Error())
-					return
-				}
+	d, err := newRoleDevice(adapter, role, device)
+	if err != nil {
+		return Device{}, err
+	}
 
-				powChar = pChar
-				ftmsChar = ftmsControlPointChar
-				close(done)
-			}()
-		})
+	info, battery := readDeviceInfo(device)
+	d.Info = map[Role]DeviceInfo{role: info}
+	if battery != nil {
+		d.Battery = battery
+	}
+	return d, nil
+}
 
+// newRoleDevice subscribes to the characteristics the role needs
+func newRoleDevice(adapter bluetoothadapter, role Role, device bluetootdevice) (Device, error) {
+	switch role {
+	case RoleTrainer:
+		chars, err := verifyDevice(adapter, device)
 		if err != nil {
-			panic(err)
+			return Device{}, err
 		}
-	}()
+		return newTrainer(chars), nil
+	case RolePowerMeter:
+		return newPowerMeter(device)
+	case RoleHrMonitor:
+		char, err := verifyHr(device)
+		if err != nil {
+			return Device{}, err
+		}
+
+		hr := newHeartRate(char)
+		return NewDevice(WithHr(&hr.hr), WithRR(&hr.rr)), nil
+	case RoleSpeedCadence:
+		return newSpeedCadence(device)
+	default:
+		return Device{}, fmt.Errorf("unknown role %d", role)
+	}
+}
+
+// newTrainer requests control over the trainer
+// and subscribes to all of its characteristics
+func newTrainer(chars *trainerCharacteristics) Device {
+	control := newControlPoint(chars.controlPoint)
+	err := control.enable()
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	powerChar := newPower(chars.power, control)
 
-	defer func() {
-		err := adapter.StopScan()
+	err = control.requestControl()
+	if err != nil {
+		slog.Error(err.Error())
+	}
+
+	opts := []trainerOpt{WithPower(powerChar), WithControl(control)}
+	if chars.status != nil {
+		status, err := newStatus(chars.status, control)
 		if err != nil {
-			slog.Info("Could not stop scanning")
+			slog.Error(err.Error())
 		}
-	}()
+		opts = append(opts, withStatus(status))
+	}
 
-	select {
-	case <-done:
-		wg.Done()
-	case <-time.After(time.Second * 10):
-		return nil, nil, fmt.Errorf("Bluetooth deadline exceeded, no devices found...")
+	if chars.bikeData != nil {
+		bikeData := newIndoorBikeData(chars.bikeData)
+		opts = append(opts,
+			WithSpeed(&bikeData.speed),
+			WithCadence(&bikeData.cadence),
+			WithResistance(&bikeData.resistance),
+			WithHr(&bikeData.hr),
+			WithElapsedTime(&bikeData.elapsedTime),
+		)
+	} else {
+		// fall back to the revolutions of the power measurement
+		slog.Info("Trainer does not support indoor bike data")
+		opts = append(opts, WithSpeed(&powerChar.speed), WithCadence(&powerChar.cadence))
 	}
 
-	wg.Wait()
-	slog.Info("Scanning done...")
-	return powChar, ftmsChar, nil
+	return NewDevice(opts...)
 }
 
-func verifyResult(
-	adapter bluetoothadapter,
-	scanResult bluetooth.ScanResult,
-) (*bluetooth.DeviceCharacteristic, *bluetooth.DeviceCharacteristic, error) {
-	slog.Info("Checking device...")
-
-	device, err := adapter.Connect(
-		scanResult.Address,
-		bluetooth.ConnectionParams{
-			ConnectionTimeout: bluetooth.NewDuration(2 * time.Second),
-		},
-	)
+// newPowerMeter subscribes to the power of e.g. power pedals,
+// and to the cadence and speed when it measures them.
+func newPowerMeter(device bluetootdevice) (Device, error) {
+	services, err := device.DiscoverServices([]bluetooth.UUID{powServiceUuid})
+	if err != nil || len(services) != 1 {
+		return Device{}, fmt.Errorf("Device does not have cycling power enabled")
+	}
 
+	char, err := getChar(services[0], cyclingPowerCharacteristicUuid)
 	if err != nil {
-		return nil, nil, err
+		return Device{}, fmt.Errorf("Could not get characteristics: %w", err)
+	}
+
+	power := newPower(char, nil)
+	opts := []trainerOpt{WithPower(power), WithSpeed(&power.speed), WithCadence(&power.cadence)}
+
+	// the control point is optional, it is needed to calibrate
+	if controlChar, err := getChar(services[0], cpControlPointCharUuid); err == nil {
+		control := newCyclingPowerControlPoint(controlChar)
+		if err := control.enable(); err != nil {
+			slog.Error(err.Error())
+		} else {
+			opts = append(opts, withOffset(control))
+		}
 	}
 
-	return verifyDevice(adapter, device)
+	return NewDevice(opts...), nil
+}
+
+// trainerCharacteristics holds the characteristics of a trainer
+type trainerCharacteristics struct {
+	// power gives power notifications
+	power bluetoothcharacteristic
+	// controlPoint is used to control the trainer, e.g. to set the power
+	controlPoint bluetoothcharacteristic
+	// bikeData gives speed, cadence, ... notifications.
+	// It is nil when the trainer does not support it.
+	bikeData bluetoothcharacteristic
+	// status notifies about changes of the trainer state.
+	// It is nil when the trainer does not support it.
+	status bluetoothcharacteristic
+}
+
+// newStatus subscribes to the status of the trainer. When another
+// app takes control over the trainer, control is requested again.
+func newStatus(char bluetoothcharacteristic, control *controlPoint) (*statusCharacteristic, error) {
+	status := &statusCharacteristic{char: char}
+
+	events, _ := status.Subscribe(context.Background(), 1)
+	go func() {
+		for e := range events.C {
+			if e.Status != StatusControlPermissionLost {
+				continue
+			}
+
+			if err := control.requestControl(); err != nil {
+				slog.Error("could not regain control: " + err.Error())
+			}
+		}
+	}()
+
+	return status, status.enable()
 }
 
 func verifyDevice(
 	adapter bluetoothadapter,
-	device bluetooth.Device,
-) (*bluetooth.DeviceCharacteristic, *bluetooth.DeviceCharacteristic, error) {
+	device bluetootdevice,
+) (*trainerCharacteristics, error) {
 	dservices, err := device.DiscoverServices(
 		[]bluetooth.UUID{powServiceUuid, ftmsServiceUuid},
 	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Device does not have cycling power enabled")
+		return nil, fmt.Errorf("Device does not have cycling power enabled")
 	}
 
 	hasCyclingPowerAndFTMS := len(dservices) == 2
 	if !hasCyclingPowerAndFTMS {
-		return nil, nil, fmt.Errorf("Device does not have all required services")
+		return nil, fmt.Errorf("Device does not have all required services")
 	}
 
 	cyclingPowerService, ftmsService := dservices[0], dservices[1]
-	pChar, err := getChar(&cyclingPowerService, cyclingPowerCharacteristicUuid)
+	pChar, err := getChar(cyclingPowerService, cyclingPowerCharacteristicUuid)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Could not get characteristics " + err.Error())
+		return nil, fmt.Errorf("Could not get characteristics: %w", err)
 	}
 
-	ftmsControlPointChar, err := getChar(&ftmsService, FTMSCharUuid)
+	ftmsControlPointChar, err := getChar(ftmsService, FTMSCharUuid)
 	if err != nil {
-		return nil, nil, fmt.Errorf("Could not scan all characteristics of ftms service")
+		return nil, fmt.Errorf("Could not scan all characteristics of ftms service")
 	}
 
-	return &pChar, &ftmsControlPointChar, nil
+	chars := &trainerCharacteristics{
+		power:        pChar,
+		controlPoint: ftmsControlPointChar,
+	}
+
+	// indoor bike data is optional, without it there is no speed and cadence
+	bikeDataChar, err := getChar(ftmsService, indoorBikeDataCharUuid)
+	if err == nil {
+		chars.bikeData = bikeDataChar
+	}
+
+	statusChar, err := getChar(ftmsService, machineStatusCharUuid)
+	if err == nil {
+		chars.status = statusChar
+	}
+
+	return chars, nil
 }
 
 func getChar(
-	service *bluetooth.DeviceService,
+	service bluetoothservice,
 	charUuid bluetooth.UUID,
-) (bluetooth.DeviceCharacteristic, error) {
+) (bluetoothcharacteristic, error) {
 	chars, err := service.DiscoverCharacteristics(
 		[]bluetooth.UUID{charUuid},
 	)
 
 	if err != nil {
-		return bluetooth.DeviceCharacteristic{}, err
+		return nil, err
 	}
 
 	charsOk := len(chars) == 1
 	if !charsOk {
-		return bluetooth.DeviceCharacteristic{}, fmt.Errorf("Service does not have characteristic")
+		return nil, fmt.Errorf("Service does not have characteristic")
 	}
 
 	return chars[0], nil
@@ -0,0 +1,167 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// op codes of the calibrations, see section 4.16.2.20 of the FTMS
+// spec and section 3.4.2 of the cycling power service spec
+const (
+	opSpinDownControl byte = 0x13
+	spinDownStart     byte = 0x01
+	spinDownIgnore    byte = 0x02
+
+	opStartOffsetCompensation byte = 0x0c
+	opCpResponseCode          byte = 0x20
+	// offsetCompensationTimeout is how long a power meter can take to
+	// calibrate, it needs a few seconds without force on the cranks.
+	offsetCompensationTimeout = 10 * time.Second
+)
+
+var (
+	// ErrCalibrationNotSupported is returned when no
+	// connected device supports the calibration.
+	ErrCalibrationNotSupported = errors.New("calibration not supported")
+	// ErrSpinDownFailed is returned when the trainer reports the spin down failed
+	ErrSpinDownFailed = errors.New("spin down failed")
+)
+
+// SpinDownStatus is the parameter of the spin down status of the trainer
+type SpinDownStatus byte
+
+const (
+	SpinDownRequested    SpinDownStatus = 0x01
+	SpinDownSuccess      SpinDownStatus = 0x02
+	SpinDownError        SpinDownStatus = 0x03
+	SpinDownStopPedaling SpinDownStatus = 0x04
+)
+
+func (s SpinDownStatus) String() string {
+	switch s {
+	case SpinDownRequested:
+		return "spin down requested"
+	case SpinDownSuccess:
+		return "success"
+	case SpinDownError:
+		return "error"
+	case SpinDownStopPedaling:
+		return "stop pedaling"
+	default:
+		return fmt.Sprintf("spin down status 0x%02x", byte(s))
+	}
+}
+
+// SpinDownTarget is the speed range the rider has to get
+// in before coasting for the spin down, in m/h
+type SpinDownTarget struct {
+	Low  int
+	High int
+}
+
+func (c *controlPoint) StartSpinDown() (SpinDownTarget, error) {
+	response, err := c.executeWithControl([]byte{opSpinDownControl, spinDownStart})
+	if err != nil {
+		return SpinDownTarget{}, err
+	}
+
+	// the speeds have a resolution of 0.01km/h
+	r := byteReader{buf: response.params}
+	target := SpinDownTarget{Low: int(r.uint16()) * 10, High: int(r.uint16()) * 10}
+	if r.err != nil {
+		return SpinDownTarget{}, fmt.Errorf("invalid spin down response: %w", r.err)
+	}
+	return target, nil
+}
+
+// SpinDown is a running spin down calibration of the trainer
+type SpinDown struct {
+	// Target is the speed to get in before coasting
+	Target SpinDownTarget
+	events *Subscription[StatusEvent]
+	// err tells why the events ended
+	err func() error
+}
+
+// StartSpinDown asks the trainer to start the spin down calibration. The
+// rider speeds up until the trainer tells to stop pedaling and coasts
+// until the trainer is done, SpinDown.Next follows the progress until
+// ctx is done.
+func (d *Device) StartSpinDown(ctx context.Context) (*SpinDown, error) {
+	if d.Control == nil || d.status == nil {
+		return nil, ErrCalibrationNotSupported
+	}
+
+	// listen before starting, the trainer reports the progress right away
+	events, _ := d.status.Subscribe(ctx, 4)
+	s := &SpinDown{events: events, err: ctx.Err}
+
+	target, err := d.Control.StartSpinDown()
+	if err != nil {
+		events.Unsubscribe()
+		return nil, err
+	}
+
+	s.Target = target
+	return s, nil
+}
+
+// Next waits for the next spin down status of the trainer. It returns
+// ErrSpinDownFailed when the trainer reports an error.
+func (s *SpinDown) Next(ctx context.Context) (SpinDownStatus, error) {
+	for {
+		select {
+		case e, ok := <-s.events.C:
+			if !ok {
+				return 0, s.err()
+			}
+			if e.Status != StatusSpinDown || len(e.Params) == 0 {
+				continue
+			}
+
+			status := SpinDownStatus(e.Params[0])
+			if status == SpinDownError {
+				return status, ErrSpinDownFailed
+			}
+			return status, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// newCyclingPowerControlPoint is the control point of a power meter
+func newCyclingPowerControlPoint(char bluetoothcharacteristic) *controlPoint {
+	c := newControlPoint(char)
+	c.responseCode = opCpResponseCode
+	c.timeout = offsetCompensationTimeout
+	return c
+}
+
+// compensateOffset calibrates the zero offset of the power meter,
+// it returns the new offset as a raw value of the power meter.
+func (c *controlPoint) compensateOffset() (int, error) {
+	response, err := c.execute([]byte{opStartOffsetCompensation})
+	if err != nil {
+		return 0, err
+	}
+
+	r := byteReader{buf: response.params}
+	offset := int(r.int16())
+	if r.err != nil {
+		return 0, fmt.Errorf("invalid offset compensation response: %w", r.err)
+	}
+	return offset, nil
+}
+
+// CompensateOffset calibrates the zero offset of the power meter. The
+// cranks must not be touched meanwhile. It returns the new offset as
+// a raw value, the unit is up to the power meter.
+func (d *Device) CompensateOffset() (int, error) {
+	if d.offset == nil {
+		return 0, ErrCalibrationNotSupported
+	}
+	return d.offset.compensateOffset()
+}
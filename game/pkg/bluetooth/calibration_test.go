@@ -0,0 +1,112 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSpinDown(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	spinDown, err := d.StartSpinDown(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spinDown.Target != (SpinDownTarget{Low: 20000, High: 30000}) {
+		t.Errorf("expected a target of 20 to 30km/h, got %+v", spinDown.Target)
+	}
+
+	next := func(expected SpinDownStatus) {
+		t.Helper()
+		if status, err := spinDown.Next(ctx); err != nil || status != expected {
+			t.Fatalf("expected %s, got %s (%v)", expected, status, err)
+		}
+	}
+
+	next(SpinDownRequested)
+
+	// 200W is fast enough to get above the target
+	trainer.step(time.Second)
+	next(SpinDownStopPedaling)
+
+	trainer.setRider(0, 0)
+	trainer.step(time.Second)
+	next(SpinDownSuccess)
+}
+
+func TestSpinDownFailed(t *testing.T) {
+	status := &statusCharacteristic{}
+	d := NewDevice(WithControl(mockController{}), withStatus(status))
+	spinDown, err := d.StartSpinDown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status.publish(StatusEvent{Status: StatusSpinDown, Params: []byte{byte(SpinDownError)}})
+	if _, err := spinDown.Next(context.Background()); !errors.Is(err, ErrSpinDownFailed) {
+		t.Errorf("expected the spin down to fail, got %v", err)
+	}
+}
+
+func TestSpinDownStops(t *testing.T) {
+	status := &statusCharacteristic{}
+	d := NewDevice(WithControl(mockController{}), withStatus(status))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	spinDown, err := d.StartSpinDown(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the spin down stops listening to the trainer with its context
+	cancel()
+	if _, err := spinDown.Next(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the spin down to stop, got %v", err)
+	}
+
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	if len(status.subs) != 0 {
+		t.Errorf("expected the status events not to be subscribed to, got %d subscriptions", len(status.subs))
+	}
+}
+
+func TestSpinDownNotSupported(t *testing.T) {
+	d := NewDevice()
+	if _, err := d.StartSpinDown(context.Background()); !errors.Is(err, ErrCalibrationNotSupported) {
+		t.Errorf("expected a device without control not to spin down, got %v", err)
+	}
+	if _, err := d.CompensateOffset(); !errors.Is(err, ErrCalibrationNotSupported) {
+		t.Errorf("expected a device without control point not to calibrate, got %v", err)
+	}
+}
+
+func TestCompensateOffset(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d, err := newPowerMeter(trainer.emulatedDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cranks must not move
+	_, err = d.CompensateOffset()
+	var cpErr *ControlPointError
+	if !errors.As(err, &cpErr) || cpErr.Result != ResultOperationFailed {
+		t.Errorf("expected the calibration to fail while pedaling, got %v", err)
+	}
+
+	trainer.setRider(0, 0)
+	offset, err := d.CompensateOffset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset != emulatedOffset {
+		t.Errorf("expected an offset of %d, got %d", emulatedOffset, offset)
+	}
+}
@@ -0,0 +1,204 @@
+package bluetooth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// op codes of the fitness machine control point, see section 4.16 of the FTMS spec
+const (
+	opRequestControl  byte = 0x00
+	opSetTargetPower  byte = 0x05
+	opResponseCode    byte = 0x80
+	controlRetries         = 3
+	controlRetryDelay      = 500 * time.Millisecond
+)
+
+// ResultCode is the result of a control point request
+type ResultCode byte
+
+const (
+	ResultSuccess             ResultCode = 0x01
+	ResultNotSupported        ResultCode = 0x02
+	ResultInvalidParameter    ResultCode = 0x03
+	ResultOperationFailed     ResultCode = 0x04
+	ResultControlNotPermitted ResultCode = 0x05
+)
+
+func (r ResultCode) String() string {
+	switch r {
+	case ResultSuccess:
+		return "success"
+	case ResultNotSupported:
+		return "op code not supported"
+	case ResultInvalidParameter:
+		return "invalid parameter"
+	case ResultOperationFailed:
+		return "operation failed"
+	case ResultControlNotPermitted:
+		return "control not permitted"
+	default:
+		return fmt.Sprintf("unknown result 0x%02x", byte(r))
+	}
+}
+
+var (
+	// ErrControlNotPermitted is returned when the trainer does not
+	// accept commands because control was not granted.
+	ErrControlNotPermitted = errors.New("control not permitted")
+	// ErrNoResponse is returned when the trainer did not answer a request in time
+	ErrNoResponse = errors.New("trainer did not respond")
+)
+
+// ControlPointError is returned when the trainer did not accept a request
+type ControlPointError struct {
+	OpCode byte
+	Result ResultCode
+}
+
+func (e *ControlPointError) Error() string {
+	return fmt.Sprintf("trainer rejected op code 0x%02x: %s", e.OpCode, e.Result)
+}
+
+func (e *ControlPointError) Is(target error) bool {
+	return target == ErrControlNotPermitted && e.Result == ResultControlNotPermitted
+}
+
+// controlPointResponse is the indication the trainer
+// sends after every write to the control point.
+type controlPointResponse struct {
+	opCode byte
+	result ResultCode
+	params []byte
+}
+
+func decodeControlPointResponse(buf []byte) (controlPointResponse, error) {
+	return decodeResponse(buf, opResponseCode)
+}
+
+// decodeResponse decodes the response of a control point, the
+// control points of FTMS and cycling power use another response code.
+func decodeResponse(buf []byte, responseCode byte) (controlPointResponse, error) {
+	if len(buf) < 3 || buf[0] != responseCode {
+		return controlPointResponse{}, fmt.Errorf("invalid control point response % x", buf)
+	}
+
+	return controlPointResponse{
+		opCode: buf[1],
+		result: ResultCode(buf[2]),
+		params: buf[3:],
+	}, nil
+}
+
+// controlPoint sends requests to the fitness machine control point
+// and waits for the trainer to respond to them.
+type controlPoint struct {
+	char  bluetoothcharacteristic
+	write func([]byte) (int, error)
+	// responseCode is the op code of the responses
+	responseCode byte
+
+	// the spec allows only one request at a time
+	mu        sync.Mutex
+	responses chan controlPointResponse
+	timeout   time.Duration
+}
+
+func newControlPoint(char bluetoothcharacteristic) *controlPoint {
+	return &controlPoint{
+		char:         char,
+		write:        char.Write,
+		responseCode: opResponseCode,
+		responses:    make(chan controlPointResponse, 1),
+		timeout:      2 * time.Second,
+	}
+}
+
+// enable subscribes to the indications with the responses
+func (c *controlPoint) enable() error {
+	return c.char.EnableNotifications(c.indicate)
+}
+
+func (c *controlPoint) indicate(buf []byte) {
+	response, err := decodeResponse(buf, c.responseCode)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	// nobody is waiting when the response comes too late, drop it
+	select {
+	case c.responses <- response:
+	default:
+		slog.Debug(fmt.Sprintf("dropped control point response for op code 0x%02x", response.opCode))
+	}
+}
+
+// execute writes a request, the first byte is the op code, and waits for
+// the response. A rejected request returns a *ControlPointError.
+func (c *controlPoint) execute(data []byte) (controlPointResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// forget responses of requests that timed out before
+	select {
+	case <-c.responses:
+	default:
+	}
+
+	if _, err := c.write(data); err != nil {
+		return controlPointResponse{}, err
+	}
+
+	timeout := time.After(c.timeout)
+	for {
+		select {
+		case response := <-c.responses:
+			if response.opCode != data[0] {
+				continue
+			}
+
+			if response.result != ResultSuccess {
+				return response, &ControlPointError{OpCode: response.opCode, Result: response.result}
+			}
+			return response, nil
+		case <-timeout:
+			return controlPointResponse{}, fmt.Errorf("op code 0x%02x: %w", data[0], ErrNoResponse)
+		}
+	}
+}
+
+// requestControl initiates the procedure to request control over the
+// fitness machine. The request is retried when the trainer does not
+// permit control yet, e.g. because another app still has it.
+func (c *controlPoint) requestControl() error {
+	var err error
+	for i := range controlRetries {
+		_, err = c.execute([]byte{opRequestControl})
+		if !errors.Is(err, ErrControlNotPermitted) {
+			return err
+		}
+
+		slog.Info(fmt.Sprintf("Trainer did not permit control, retrying (%d/%d)", i+1, controlRetries))
+		time.Sleep(controlRetryDelay * time.Duration(i+1))
+	}
+
+	return err
+}
+
+// executeWithControl executes a request and requests control again
+// when the trainer lost it, after which the request is retried once.
+func (c *controlPoint) executeWithControl(data []byte) (controlPointResponse, error) {
+	response, err := c.execute(data)
+	if !errors.Is(err, ErrControlNotPermitted) {
+		return response, err
+	}
+
+	if err := c.requestControl(); err != nil {
+		return response, err
+	}
+	return c.execute(data)
+}
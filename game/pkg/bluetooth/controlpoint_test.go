@@ -0,0 +1,160 @@
+package bluetooth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeControlPoint answers every write with the next result in results
+func fakeControlPoint(results ...ResultCode) (*controlPoint, *[][]byte) {
+	var written [][]byte
+	c := &controlPoint{
+		responseCode: opResponseCode,
+		responses:    make(chan controlPointResponse, 1),
+		timeout:      50 * time.Millisecond,
+	}
+	c.write = func(data []byte) (int, error) {
+		written = append(written, data)
+		if len(results) == 0 {
+			return len(data), nil
+		}
+
+		result := results[0]
+		results = results[1:]
+		c.indicate([]byte{opResponseCode, data[0], byte(result)})
+		return len(data), nil
+	}
+	return c, &written
+}
+
+func TestDecodeControlPointResponse(t *testing.T) {
+	response, err := decodeControlPointResponse([]byte{0x80, 0x05, 0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.opCode != opSetTargetPower || response.result != ResultSuccess {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	for _, buf := range [][]byte{nil, {0x80, 0x05}, {0x05, 0x05, 0x01}} {
+		if _, err := decodeControlPointResponse(buf); err == nil {
+			t.Errorf("expected an error for % x", buf)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	c, _ := fakeControlPoint(ResultSuccess)
+	if _, err := c.execute(encode(200)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ = fakeControlPoint(ResultInvalidParameter)
+	_, err := c.execute(encode(200))
+	var cpErr *ControlPointError
+	if !errors.As(err, &cpErr) || cpErr.Result != ResultInvalidParameter {
+		t.Errorf("expected invalid parameter, got %v", err)
+	}
+
+	c, _ = fakeControlPoint()
+	if _, err := c.execute(encode(200)); !errors.Is(err, ErrNoResponse) {
+		t.Errorf("expected no response, got %v", err)
+	}
+}
+
+func TestExecuteIgnoresOtherOpCodes(t *testing.T) {
+	c, _ := fakeControlPoint()
+	c.timeout = time.Second
+	c.write = func(data []byte) (int, error) {
+		c.indicate([]byte{opResponseCode, opRequestControl, byte(ResultSuccess)})
+		go func() {
+			// give execute the time to read the first response
+			time.Sleep(10 * time.Millisecond)
+			c.indicate([]byte{opResponseCode, data[0], byte(ResultSuccess)})
+		}()
+		return len(data), nil
+	}
+
+	if _, err := c.execute(encode(200)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecuteWithControl(t *testing.T) {
+	c, written := fakeControlPoint(ResultControlNotPermitted, ResultSuccess, ResultSuccess)
+	if _, err := c.executeWithControl(encode(200)); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := []byte{}
+	for _, w := range *written {
+		ops = append(ops, w[0])
+	}
+
+	expected := []byte{opSetTargetPower, opRequestControl, opSetTargetPower}
+	if string(ops) != string(expected) {
+		t.Errorf("expected op codes % x, got % x", expected, ops)
+	}
+}
+
+func TestDecodeStatusEvent(t *testing.T) {
+	event, err := decodeStatusEvent([]byte{0x08, 0xc8, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Status != StatusTargetPowerChanged || len(event.Params) != 2 {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	if _, err := decodeStatusEvent(nil); err == nil {
+		t.Error("expected an error for an empty notification")
+	}
+}
+
+func TestEncodeSimulation(t *testing.T) {
+	data, err := encodeSimulation(SimulationParams{WindSpeed: -1.5, Grade: 4.5, Crr: 0.004, CdA: 0.51})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// -1500 mm/s, 450 hundredths of a percent, 40 and 51
+	expected := []byte{opSetSimulation, 0x24, 0xfa, 0xc2, 0x01, 0x28, 0x33}
+	if string(data) != string(expected) {
+		t.Errorf("expected % x, got % x", expected, data)
+	}
+
+	for _, p := range []SimulationParams{{Grade: 400}, {Crr: -0.1}, {CdA: 3}, {WindSpeed: 40}} {
+		if _, err := encodeSimulation(p); err == nil {
+			t.Errorf("expected an error for %+v", p)
+		}
+	}
+}
+
+func TestEncodeResistance(t *testing.T) {
+	data, err := encodeResistance(12.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != string([]byte{opSetTargetResistance, 123}) {
+		t.Errorf("unexpected encoding % x", data)
+	}
+
+	if _, err := encodeResistance(30); err == nil {
+		t.Error("expected an error for a resistance level of 30")
+	}
+}
+
+func TestSetSimulation(t *testing.T) {
+	c, written := fakeControlPoint(ResultSuccess)
+	if err := c.SetSimulation(DefaultSimulation); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*written) != 1 || (*written)[0][0] != opSetSimulation {
+		t.Errorf("expected a single simulation request, got % x", *written)
+	}
+}
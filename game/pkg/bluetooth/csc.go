@@ -0,0 +1,167 @@
+package bluetooth
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// flags of the CSC measurement, see section 3.55 of the GATT specification supplement
+const (
+	cscWheelRevolutions uint8 = 1 << iota
+	cscCrankRevolutions
+)
+
+// wheelCircumference of a 700x25c tire in m
+const wheelCircumference = 2.105
+
+// cscMeasurement holds a decoded CSC measurement notification.
+// The revolutions are cumulative, the event times tell when
+// the last revolution happened in 1/1024s.
+type cscMeasurement struct {
+	flags uint8
+
+	WheelRevolutions uint32
+	WheelEventTime   uint16
+	CrankRevolutions uint16
+	CrankEventTime   uint16
+}
+
+func (m cscMeasurement) has(flag uint8) bool {
+	return m.flags&flag != 0
+}
+
+func decodeCSC(buf []byte) (cscMeasurement, error) {
+	r := byteReader{buf: buf}
+	m := cscMeasurement{flags: r.uint8()}
+
+	if m.has(cscWheelRevolutions) {
+		m.WheelRevolutions = uint32(r.uint16()) | uint32(r.uint16())<<16
+		m.WheelEventTime = r.uint16()
+	}
+	if m.has(cscCrankRevolutions) {
+		m.CrankRevolutions = r.uint16()
+		m.CrankEventTime = r.uint16()
+	}
+
+	if r.err != nil {
+		return cscMeasurement{}, fmt.Errorf("could not decode csc measurement: %w", r.err)
+	}
+	return m, nil
+}
+
+// maxIdle is the number of notifications without a new
+// revolution after which the rider is considered stopped
+const maxIdle = 3
+
+// revolutionCounter turns a cumulative revolution count and the
+// time of the last revolution into revolutions per minute.
+// Both counters roll over, the revolutions after revBits bits.
+type revolutionCounter struct {
+	revBits        uint
+	ticksPerSecond float64
+
+	started bool
+	revs    uint32
+	time    uint16
+	idle    int
+}
+
+// update returns the revolutions per minute since the previous
+// reading, ok is false when there is nothing new to report.
+func (c *revolutionCounter) update(revs uint32, eventTime uint16) (rpm float64, ok bool) {
+	if !c.started {
+		c.started = true
+		c.revs, c.time = revs, eventTime
+		return 0, false
+	}
+
+	mask := uint32(uint64(1)<<c.revBits - 1)
+	deltaRevs := (revs - c.revs) & mask
+	// unsigned subtraction takes care of the roll over
+	deltaTime := eventTime - c.time
+	c.revs, c.time = revs, eventTime
+
+	if deltaTime == 0 {
+		c.idle++
+		if c.idle == maxIdle {
+			return 0, true
+		}
+		return 0, false
+	}
+
+	c.idle = 0
+	return float64(deltaRevs) * 60 * c.ticksPerSecond / float64(deltaTime), true
+}
+
+// cscCharacteristic turns the notifications of a
+// speed and cadence sensor into speed and cadence.
+type cscCharacteristic struct {
+	char bluetoothcharacteristic
+
+	once sync.Once
+	err  error
+
+	wheel revolutionCounter
+	crank revolutionCounter
+
+	speed   field
+	cadence field
+}
+
+func newCSC(char bluetoothcharacteristic) *cscCharacteristic {
+	c := &cscCharacteristic{
+		char:  char,
+		wheel: revolutionCounter{revBits: 32, ticksPerSecond: 1024},
+		crank: revolutionCounter{revBits: 16, ticksPerSecond: 1024},
+	}
+	c.speed.source = c
+	c.cadence.source = c
+	return c
+}
+
+func (c *cscCharacteristic) enable() error {
+	c.once.Do(func() {
+		c.err = c.char.EnableNotifications(c.notify)
+	})
+	return c.err
+}
+
+func (c *cscCharacteristic) notify(buf []byte) {
+	m, err := decodeCSC(buf)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	if m.has(cscWheelRevolutions) {
+		if rpm, ok := c.wheel.update(m.WheelRevolutions, m.WheelEventTime); ok {
+			// in m/h
+			c.speed.WriteValue(int(rpm * wheelCircumference * 60))
+		}
+	}
+	if m.has(cscCrankRevolutions) {
+		if rpm, ok := c.crank.update(uint32(m.CrankRevolutions), m.CrankEventTime); ok {
+			c.cadence.WriteValue(int(rpm))
+		}
+	}
+}
+
+// newSpeedCadence subscribes to a speed and cadence sensor,
+// a cadence pod only fills in the cadence.
+func newSpeedCadence(device bluetootdevice) (Device, error) {
+	services, err := device.DiscoverServices([]bluetooth.UUID{speedCadenceServiceUuid})
+	if err != nil || len(services) != 1 {
+		return Device{}, fmt.Errorf("Device does not have the speed and cadence service")
+	}
+
+	char, err := getChar(services[0], cscMeasurementCharUuid)
+	if err != nil {
+		return Device{}, fmt.Errorf("Could not get csc measurement: %w", err)
+	}
+
+	csc := newCSC(char)
+	return NewDevice(WithSpeed(&csc.speed), WithCadence(&csc.cadence)), nil
+}
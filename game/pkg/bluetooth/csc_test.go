@@ -0,0 +1,102 @@
+package bluetooth
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeCSC(t *testing.T) {
+	// 70000 wheel revolutions at 1024, 300 crank revolutions at 2048
+	buf := []byte{0x03, 0x70, 0x11, 0x01, 0x00, 0x00, 0x04, 0x2c, 0x01, 0x00, 0x08}
+	m, err := decodeCSC(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := cscMeasurement{
+		flags:            0x03,
+		WheelRevolutions: 70000,
+		WheelEventTime:   1024,
+		CrankRevolutions: 300,
+		CrankEventTime:   2048,
+	}
+	if m != expected {
+		t.Errorf("expected %+v, got %+v", expected, m)
+	}
+
+	if _, err := decodeCSC([]byte{0x02, 0x2c, 0x01}); err == nil {
+		t.Error("expected an error for a truncated notification")
+	}
+}
+
+func TestRevolutionCounter(t *testing.T) {
+	tests := []struct {
+		name     string
+		revBits  uint
+		prevRevs uint32
+		prevTime uint16
+		revs     uint32
+		time     uint16
+		expected float64
+	}{
+		{
+			name:     "90 rpm",
+			revBits:  16,
+			prevRevs: 10, prevTime: 1024,
+			// 3 revolutions in 2 seconds
+			revs: 13, time: 3072,
+			expected: 90,
+		},
+		{
+			name:     "event time rolls over",
+			revBits:  16,
+			prevRevs: 10, prevTime: 65024,
+			revs: 11, time: 512,
+			expected: 60,
+		},
+		{
+			name:     "revolutions roll over",
+			revBits:  16,
+			prevRevs: 65535, prevTime: 0,
+			revs: 1, time: 1024,
+			expected: 120,
+		},
+		{
+			name:     "32 bit revolutions roll over",
+			revBits:  32,
+			prevRevs: math.MaxUint32, prevTime: 0,
+			revs: 0, time: 512,
+			expected: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := revolutionCounter{revBits: tt.revBits, ticksPerSecond: 1024}
+			if _, ok := c.update(tt.prevRevs, tt.prevTime); ok {
+				t.Error("the first reading has nothing to compare with")
+			}
+
+			rpm, ok := c.update(tt.revs, tt.time)
+			if !ok || rpm != tt.expected {
+				t.Errorf("expected %v rpm, got %v (%v)", tt.expected, rpm, ok)
+			}
+		})
+	}
+}
+
+func TestRevolutionCounterStops(t *testing.T) {
+	c := revolutionCounter{revBits: 16, ticksPerSecond: 1024}
+	c.update(10, 1024)
+
+	for i := range maxIdle - 1 {
+		if _, ok := c.update(10, 1024); ok {
+			t.Fatalf("reading %d should not report anything yet", i)
+		}
+	}
+
+	rpm, ok := c.update(10, 1024)
+	if !ok || rpm != 0 {
+		t.Errorf("expected 0 rpm after %d readings without a revolution, got %v", maxIdle, rpm)
+	}
+}
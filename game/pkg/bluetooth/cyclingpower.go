@@ -0,0 +1,107 @@
+package bluetooth
+
+import "fmt"
+
+// flags of the cycling power measurement, see section 3.65 of the GATT
+// specification supplement. Most flags tell if a field is present.
+const (
+	cpPedalPowerBalance uint16 = 1 << iota
+	// cpPedalPowerBalanceLeft tells the balance is of the left pedal
+	cpPedalPowerBalanceLeft
+	cpAccumulatedTorque
+	// cpTorqueCrankBased tells the torque is measured at the crank instead of the wheel
+	cpTorqueCrankBased
+	cpWheelRevolutions
+	cpCrankRevolutions
+	cpExtremeForces
+	cpExtremeTorques
+	cpExtremeAngles
+	cpTopDeadSpotAngle
+	cpBottomDeadSpotAngle
+	cpAccumulatedEnergy
+	// cpOffsetCompensation tells the power meter needs to be calibrated
+	cpOffsetCompensation
+)
+
+// cyclingPowerMeasurement holds a decoded cycling power measurement.
+// Only the fields with their flag set in flags are filled in. The
+// revolutions are cumulative, the event times tell when the last
+// revolution happened, in 1/2048s for the wheel and 1/1024s for the crank.
+type cyclingPowerMeasurement struct {
+	flags uint16
+
+	Power             int     // in W
+	PedalPowerBalance float64 // in %
+	AccumulatedTorque float64 // in Nm
+
+	WheelRevolutions uint32
+	WheelEventTime   uint16
+	CrankRevolutions uint16
+	CrankEventTime   uint16
+
+	MaxForce  int     // in N
+	MinForce  int     // in N
+	MaxTorque float64 // in Nm
+	MinTorque float64 // in Nm
+	MaxAngle  int     // in degrees
+	MinAngle  int     // in degrees
+
+	TopDeadSpotAngle    int // in degrees
+	BottomDeadSpotAngle int // in degrees
+	AccumulatedEnergy   int // in kJ
+}
+
+func (m cyclingPowerMeasurement) has(flag uint16) bool {
+	return m.flags&flag != 0
+}
+
+func decodeCyclingPower(buf []byte) (cyclingPowerMeasurement, error) {
+	r := byteReader{buf: buf}
+	m := cyclingPowerMeasurement{flags: r.uint16()}
+	m.Power = int(r.int16())
+
+	// the balance has a resolution of 1/2%
+	if m.has(cpPedalPowerBalance) {
+		m.PedalPowerBalance = float64(r.uint8()) / 2
+	}
+	// torque has a resolution of 1/32Nm
+	if m.has(cpAccumulatedTorque) {
+		m.AccumulatedTorque = float64(r.uint16()) / 32
+	}
+	if m.has(cpWheelRevolutions) {
+		m.WheelRevolutions = uint32(r.uint16()) | uint32(r.uint16())<<16
+		m.WheelEventTime = r.uint16()
+	}
+	if m.has(cpCrankRevolutions) {
+		m.CrankRevolutions = r.uint16()
+		m.CrankEventTime = r.uint16()
+	}
+	if m.has(cpExtremeForces) {
+		m.MaxForce = int(r.int16())
+		m.MinForce = int(r.int16())
+	}
+	if m.has(cpExtremeTorques) {
+		m.MaxTorque = float64(r.int16()) / 32
+		m.MinTorque = float64(r.int16()) / 32
+	}
+	// both angles are 12 bits, packed in 3 bytes
+	if m.has(cpExtremeAngles) {
+		angles := r.uint24()
+		m.MaxAngle = int(angles & 0xfff)
+		m.MinAngle = int(angles >> 12)
+	}
+	if m.has(cpTopDeadSpotAngle) {
+		m.TopDeadSpotAngle = int(r.uint16())
+	}
+	if m.has(cpBottomDeadSpotAngle) {
+		m.BottomDeadSpotAngle = int(r.uint16())
+	}
+	if m.has(cpAccumulatedEnergy) {
+		m.AccumulatedEnergy = int(r.uint16())
+	}
+
+	if r.err != nil {
+		return cyclingPowerMeasurement{}, fmt.Errorf("could not decode cycling power measurement: %w", r.err)
+	}
+	return m, nil
+}
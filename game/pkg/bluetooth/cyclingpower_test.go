@@ -0,0 +1,181 @@
+package bluetooth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDecodeCyclingPower(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      []byte
+		expected cyclingPowerMeasurement
+	}{
+		{
+			name:     "power only",
+			buf:      []byte{0x00, 0x00, 0xfa, 0x00},
+			expected: cyclingPowerMeasurement{Power: 250},
+		},
+		{
+			name:     "negative power",
+			buf:      []byte{0x00, 0x00, 0xf6, 0xff},
+			expected: cyclingPowerMeasurement{Power: -10},
+		},
+		{
+			name: "crank revolutions",
+			buf:  []byte{0x20, 0x00, 0xc8, 0x00, 0x2c, 0x01, 0x00, 0x08},
+			expected: cyclingPowerMeasurement{
+				flags:            cpCrankRevolutions,
+				Power:            200,
+				CrankRevolutions: 300,
+				CrankEventTime:   2048,
+			},
+		},
+		{
+			name: "left pedal balance and crank revolutions",
+			buf:  []byte{0x23, 0x00, 0xe6, 0x00, 0x64, 0x2c, 0x01, 0x00, 0x04},
+			expected: cyclingPowerMeasurement{
+				flags:             cpPedalPowerBalance | cpPedalPowerBalanceLeft | cpCrankRevolutions,
+				Power:             230,
+				PedalPowerBalance: 50,
+				CrankRevolutions:  300,
+				CrankEventTime:    1024,
+			},
+		},
+		{
+			name: "torque and wheel revolutions",
+			buf:  []byte{0x14, 0x00, 0x96, 0x00, 0x40, 0x06, 0x70, 0x11, 0x01, 0x00, 0x00, 0x08},
+			expected: cyclingPowerMeasurement{
+				flags:             cpAccumulatedTorque | cpWheelRevolutions,
+				Power:             150,
+				AccumulatedTorque: 50,
+				WheelRevolutions:  70000,
+				WheelEventTime:    2048,
+			},
+		},
+		{
+			name: "extremes, dead spots and energy",
+			buf: []byte{
+				0xc0, 0x0f, 0x64, 0x00,
+				0xf4, 0x01, 0x9c, 0xff,
+				0x40, 0x00, 0xe0, 0xff,
+				0xb4, 0xe0, 0x10,
+				0x0a, 0x00, 0xbe, 0x00,
+				0x2a, 0x00,
+			},
+			expected: cyclingPowerMeasurement{
+				flags: cpExtremeForces | cpExtremeTorques | cpExtremeAngles |
+					cpTopDeadSpotAngle | cpBottomDeadSpotAngle | cpAccumulatedEnergy,
+				Power:               100,
+				MaxForce:            500,
+				MinForce:            -100,
+				MaxTorque:           2,
+				MinTorque:           -1,
+				MaxAngle:            180,
+				MinAngle:            270,
+				TopDeadSpotAngle:    10,
+				BottomDeadSpotAngle: 190,
+				AccumulatedEnergy:   42,
+			},
+		},
+		{
+			name:     "offset compensation",
+			buf:      []byte{0x00, 0x10, 0x00, 0x00},
+			expected: cyclingPowerMeasurement{flags: cpOffsetCompensation},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := decodeCyclingPower(tt.buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, m)
+			}
+		})
+	}
+}
+
+func TestDecodeCyclingPowerTruncated(t *testing.T) {
+	for _, buf := range [][]byte{
+		{0x00},
+		{0x00, 0x00, 0xfa},
+		// crank revolutions without the event time
+		{0x20, 0x00, 0xc8, 0x00, 0x2c, 0x01},
+	} {
+		if _, err := decodeCyclingPower(buf); err == nil {
+			t.Errorf("expected an error for % x", buf)
+		}
+	}
+}
+
+func TestPowerRevolutions(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications [][]byte
+		speed         []int
+		cadence       []int
+	}{
+		{
+			name: "crank revolutions roll over",
+			notifications: [][]byte{
+				{0x20, 0x00, 0xc8, 0x00, 0xff, 0xff, 0x00, 0xfc},
+				// 2 revolutions in 2 seconds
+				{0x20, 0x00, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x04},
+			},
+			cadence: []int{60},
+		},
+		{
+			name: "wheel revolutions in 1/2048s",
+			notifications: [][]byte{
+				{0x10, 0x00, 0xc8, 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0x00},
+				// 4 revolutions in 1 second
+				{0x10, 0x00, 0xc8, 0x00, 0x68, 0x00, 0x00, 0x00, 0x00, 0x08},
+			},
+			// 240rpm with a circumference of 2.105m
+			speed: []int{30312},
+		},
+		{
+			name: "the rider stops pedaling",
+			notifications: [][]byte{
+				{0x20, 0x00, 0xc8, 0x00, 0x0a, 0x00, 0x00, 0x04},
+				{0x20, 0x00, 0xc8, 0x00, 0x0c, 0x00, 0x00, 0x0c},
+				{0x20, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x0c},
+				{0x20, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x0c},
+				{0x20, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x0c},
+			},
+			cadence: []int{60, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPower(nil, nil)
+			speed, _ := p.speed.Subscribe(context.Background(), 10)
+			cadence, _ := p.cadence.Subscribe(context.Background(), 10)
+
+			for _, buf := range tt.notifications {
+				p.notify(buf)
+			}
+
+			expectValues(t, "speed", speed, tt.speed)
+			expectValues(t, "cadence", cadence, tt.cadence)
+		})
+	}
+}
+
+func expectValues(t *testing.T, name string, sub *Subscription[Sample], expected []int) {
+	t.Helper()
+
+	if len(sub.C) != len(expected) {
+		t.Fatalf("expected %d %s values, got %d", len(expected), name, len(sub.C))
+	}
+	for _, e := range expected {
+		if v := <-sub.C; v.Value != e {
+			t.Errorf("expected %s %d, got %d", name, e, v.Value)
+		}
+	}
+}
@@ -1,15 +1,45 @@
 package bluetooth
 
+import "context"
+
 type readwriter interface {
 	Write(int) (int, error)
 	ContinuousRead() error
 	AddListener(chan int) bool
+	Subscribe(ctx context.Context, size int) (*Subscription[Sample], bool)
 }
 
 type Device struct {
 	Power   readwriter
-	Speed   readwriter
-	Cadence readwriter
+	Speed   readwriter // in m/h
+	Cadence readwriter // in rpm
+
+	Resistance  readwriter
+	Hr          readwriter // in bpm
+	ElapsedTime readwriter // in s
+	// RR gives the time between two heart beats in ms,
+	// it is only available with a heart rate strap.
+	RR readwriter
+	// Battery gives the battery levels in % of the devices that
+	// notify them, the source of a sample is the role of the device.
+	Battery readwriter
+
+	// Control drives the trainer by slope or resistance instead of
+	// target power, it is nil when the trainer cannot be controlled.
+	Control Controller
+
+	// Peripherals holds the connected devices by their role
+	Peripherals map[Role]Peripheral
+	// Unreachable holds the known devices that could not be connected
+	Unreachable map[Role]Peripheral
+	// Info holds what the connected devices tell about themselves by their role
+	Info map[Role]DeviceInfo
+
+	status     *statusCharacteristic
+	connection *reconnector
+	// offset calibrates the power meter, it is nil
+	// when the power meter has no control point.
+	offset *controlPoint
 }
 
 type trainerOpt func(*Device)
@@ -32,6 +62,72 @@ func WithCadence(cad readwriter) trainerOpt {
 	}
 }
 
+func WithResistance(r readwriter) trainerOpt {
+	return func(t *Device) {
+		t.Resistance = r
+	}
+}
+
+func WithHr(hr readwriter) trainerOpt {
+	return func(t *Device) {
+		t.Hr = hr
+	}
+}
+
+func WithRR(rr readwriter) trainerOpt {
+	return func(t *Device) {
+		t.RR = rr
+	}
+}
+
+func WithElapsedTime(e readwriter) trainerOpt {
+	return func(t *Device) {
+		t.ElapsedTime = e
+	}
+}
+
+func WithBattery(b readwriter) trainerOpt {
+	return func(t *Device) {
+		t.Battery = b
+	}
+}
+
+func WithControl(c Controller) trainerOpt {
+	return func(t *Device) {
+		t.Control = c
+	}
+}
+
+func withStatus(s *statusCharacteristic) trainerOpt {
+	return func(t *Device) {
+		t.status = s
+	}
+}
+
+func withOffset(c *controlPoint) trainerOpt {
+	return func(t *Device) {
+		t.offset = c
+	}
+}
+
+// SubscribeStatus subscribes to the status events of the trainer. It
+// returns false when the trainer does not report its status.
+func (d *Device) SubscribeStatus(ctx context.Context, size int) (*Subscription[StatusEvent], bool) {
+	if d.status == nil {
+		return nil, false
+	}
+	return d.status.Subscribe(ctx, size)
+}
+
+// SubscribeConnection subscribes to the connection events of the
+// trainer. It returns false when the connection is not watched.
+func (d *Device) SubscribeConnection(ctx context.Context, size int) (*Subscription[ConnectionEvent], bool) {
+	if d.connection == nil {
+		return nil, false
+	}
+	return d.connection.events.Subscribe(ctx, size)
+}
+
 func NewDevice(opts ...trainerOpt) Device {
 	t := &Device{}
 	for _, opt := range opts {
@@ -42,14 +138,9 @@ func NewDevice(opts ...trainerOpt) Device {
 }
 
 func (d *Device) Listen() {
-	if d.Power != nil {
-		_ = d.Power.ContinuousRead()
-	}
-
-	if d.Cadence != nil {
-		_ = d.Cadence.ContinuousRead()
-	}
-	if d.Speed != nil {
-		_ = d.Speed.ContinuousRead()
+	for _, rw := range []readwriter{d.Power, d.Cadence, d.Speed, d.Resistance, d.Hr, d.ElapsedTime, d.RR, d.Battery} {
+		if rw != nil {
+			_ = rw.ContinuousRead()
+		}
 	}
 }
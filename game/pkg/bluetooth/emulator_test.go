@@ -0,0 +1,208 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func connectEmulator(t *testing.T, trainer *emulatedTrainer) *Device {
+	t.Helper()
+
+	d, err := connect(newEmulatedAdapter(trainer.emulatedDevice), NewConnectOpts(WithScanTimeout(10*time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d.Listen()
+	return d
+}
+
+// ride steps the trainer n seconds and returns the last sample of sub
+func ride(t *testing.T, trainer *emulatedTrainer, n int, sub *Subscription[Sample]) Sample {
+	t.Helper()
+
+	for range n {
+		trainer.step(time.Second)
+	}
+
+	var last Sample
+	for {
+		select {
+		case last = <-sub.C:
+		case <-time.After(100 * time.Millisecond):
+			return last
+		}
+	}
+}
+
+func TestEmulatorConnect(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+
+	if d.Peripherals[RoleTrainer].Name != "KICKR" {
+		t.Errorf("expected to connect the KICKR, got %+v", d.Peripherals)
+	}
+
+	power, _ := d.Power.Subscribe(context.Background(), 1)
+	cadence, _ := d.Cadence.Subscribe(context.Background(), 1)
+	speed, _ := d.Speed.Subscribe(context.Background(), 1)
+
+	if p := ride(t, trainer, 1, power); p.Value != 200 || p.Source != "trainer" {
+		t.Errorf("expected the 200W of the rider, got %+v", p)
+	}
+	if c := ride(t, trainer, 1, cadence); c.Value != 90 {
+		t.Errorf("expected 90rpm, got %d", c.Value)
+	}
+	if s := ride(t, trainer, 1, speed); s.Value < 30000 || s.Value > 40000 {
+		t.Errorf("expected about 35km/h for 200W, got %dm/h", s.Value)
+	}
+}
+
+func TestEmulatorErg(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+	power, _ := d.Power.Subscribe(context.Background(), 1)
+
+	if _, err := d.Power.Write(300); err != nil {
+		t.Fatal(err)
+	}
+
+	// the trainer needs a while to get there
+	if p := ride(t, trainer, 1, power); p.Value >= 300 {
+		t.Errorf("expected the power to ramp up, got %dW", p.Value)
+	}
+	if p := ride(t, trainer, 10, power); p.Value != 300 {
+		t.Errorf("expected the target of 300W, got %dW", p.Value)
+	}
+
+	// a trainer in erg mode keeps the power when the rider slows down
+	trainer.setRider(200, 60)
+	if p := ride(t, trainer, 2, power); p.Value != 300 {
+		t.Errorf("expected 300W at a lower cadence, got %dW", p.Value)
+	}
+}
+
+func TestEmulatorRegainsControl(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+	power, _ := d.Power.Subscribe(context.Background(), 1)
+
+	trainer.takeControl()
+
+	if _, err := d.Power.Write(250); err != nil {
+		t.Fatalf("expected control to be requested again, got %s", err)
+	}
+	if p := ride(t, trainer, 10, power); p.Value != 250 {
+		t.Errorf("expected 250W, got %dW", p.Value)
+	}
+}
+
+func TestEmulatorSimulation(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+	speed, _ := d.Speed.Subscribe(context.Background(), 1)
+
+	flat := ride(t, trainer, 1, speed)
+
+	climb := DefaultSimulation
+	climb.Grade = 8
+	if err := d.Control.SetSimulation(climb); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := ride(t, trainer, 1, speed); s.Value >= flat.Value/2 {
+		t.Errorf("expected to go a lot slower than %dm/h up an 8%% climb, got %dm/h", flat.Value, s.Value)
+	}
+}
+
+func TestEmulatorResistance(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+	power, _ := d.Power.Subscribe(context.Background(), 1)
+
+	if err := d.Control.SetResistance(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if p := ride(t, trainer, 1, power); p.Value != 10*wattsPerLevel {
+		t.Errorf("expected %dW at level 10, got %dW", 10*wattsPerLevel, p.Value)
+	}
+}
+
+func TestEmulatorRejectsWithoutControl(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	control := newControlPoint(trainer.controlPoint)
+	if err := control.enable(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := control.execute(encode(200))
+	if !errors.Is(err, ErrControlNotPermitted) {
+		t.Errorf("expected control not to be permitted, got %v", err)
+	}
+
+	_, err = control.executeWithControl(encode(200))
+	if err != nil {
+		t.Errorf("expected the target to be accepted after requesting control, got %s", err)
+	}
+
+	_, err = control.execute([]byte{0x42})
+	var cpErr *ControlPointError
+	if !errors.As(err, &cpErr) || cpErr.Result != ResultNotSupported {
+		t.Errorf("expected an unknown op code not to be supported, got %v", err)
+	}
+}
+
+func TestEmulatorCrankRevolutions(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	power := newPower(trainer.power, nil)
+	if err := power.ContinuousRead(); err != nil {
+		t.Fatal(err)
+	}
+	cadence, _ := power.cadence.Subscribe(context.Background(), 1)
+
+	trainer.setRider(200, 75)
+	if c := ride(t, trainer, 10, cadence); math.Abs(float64(c.Value-75)) > 1 {
+		t.Errorf("expected about 75rpm from the crank revolutions, got %d", c.Value)
+	}
+}
+
+func TestEmulatorOutOfReach(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	trainer.setInReach(false)
+
+	_, err := connect(newEmulatedAdapter(trainer.emulatedDevice), NewConnectOpts(WithScanTimeout(10*time.Millisecond)))
+	if err == nil {
+		t.Error("expected no trainer to be found")
+	}
+}
+
+func TestEmulatorKnownUnreachable(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	strap := Peripheral{Name: "Polar H10", Address: "E1:EC:00:00:00:02"}
+
+	// the strap stays off, only the grace is waited for it
+	start := time.Now()
+	d, err := connect(newEmulatedAdapter(trainer.emulatedDevice), NewConnectOpts(
+		WithScanTimeout(10*time.Second),
+		WithScanGrace(10*time.Millisecond),
+		WithKnownDevice(RoleTrainer, Peripheral{Name: "KICKR", Address: "E1:EC:00:00:00:01"}),
+		WithKnownDevice(RoleHrMonitor, strap),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected not to wait for the strap, took %s", elapsed)
+	}
+	if _, ok := d.Peripherals[RoleTrainer]; !ok {
+		t.Errorf("expected the trainer to be connected, got %+v", d.Peripherals)
+	}
+	if len(d.Unreachable) != 1 || d.Unreachable[RoleHrMonitor].Address != strap.Address {
+		t.Errorf("expected the strap to be unreachable, got %+v", d.Unreachable)
+	}
+}
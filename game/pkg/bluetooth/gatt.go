@@ -0,0 +1,56 @@
+package bluetooth
+
+import "tinygo.org/x/bluetooth"
+
+// gattAdapter connects to devices with the bluetooth adapter
+// of the system. The GATT types of the bluetooth package are
+// wrapped, so the emulator can stand in for them in tests.
+type gattAdapter struct {
+	*bluetooth.Adapter
+}
+
+func (a gattAdapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (bluetootdevice, error) {
+	device, err := a.Adapter.Connect(address, params)
+	if err != nil {
+		return nil, err
+	}
+	return gattDevice{device}, nil
+}
+
+type gattDevice struct {
+	bluetooth.Device
+}
+
+func (d gattDevice) DiscoverServices(uuids []bluetooth.UUID) ([]bluetoothservice, error) {
+	services, err := d.Device.DiscoverServices(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]bluetoothservice, len(services))
+	for i, s := range services {
+		wrapped[i] = gattService{s}
+	}
+	return wrapped, nil
+}
+
+type gattService struct {
+	bluetooth.DeviceService
+}
+
+func (s gattService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]bluetoothcharacteristic, error) {
+	chars, err := s.DeviceService.DiscoverCharacteristics(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]bluetoothcharacteristic, len(chars))
+	for i, c := range chars {
+		wrapped[i] = gattCharacteristic{c}
+	}
+	return wrapped, nil
+}
+
+type gattCharacteristic struct {
+	bluetooth.DeviceCharacteristic
+}
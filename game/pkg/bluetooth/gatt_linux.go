@@ -0,0 +1,7 @@
+package bluetooth
+
+// Write writes to the characteristic. BlueZ has no separate write with
+// response, it sends a write request when the characteristic supports it.
+func (c gattCharacteristic) Write(p []byte) (int, error) {
+	return c.WriteWithoutResponse(p)
+}
@@ -0,0 +1,121 @@
+package bluetooth
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// flags of the heart rate measurement, see section 3.106 of the GATT specification supplement
+const (
+	hrmUint16 uint8 = 1 << iota
+	hrmContactDetected
+	hrmContactSupported
+	hrmEnergyExpended
+	hrmRRIntervals
+)
+
+// heartRateMeasurement holds a decoded heart rate notification
+type heartRateMeasurement struct {
+	Hr int // in bpm
+	// Contact is false when the strap supports contact
+	// detection and does not touch the skin.
+	Contact bool
+	// Energy is the energy expended in kJ, it is 0 when not sent
+	Energy int
+	// RR holds the time between the last beats
+	RR []time.Duration
+}
+
+func decodeHeartRate(buf []byte) (heartRateMeasurement, error) {
+	r := byteReader{buf: buf}
+	flags := r.uint8()
+
+	m := heartRateMeasurement{Contact: true}
+	if flags&hrmUint16 != 0 {
+		m.Hr = int(r.uint16())
+	} else {
+		m.Hr = int(r.uint8())
+	}
+
+	if flags&hrmContactSupported != 0 {
+		m.Contact = flags&hrmContactDetected != 0
+	}
+
+	if flags&hrmEnergyExpended != 0 {
+		m.Energy = int(r.uint16())
+	}
+
+	// every interval takes two bytes, with a resolution of 1/1024s
+	if flags&hrmRRIntervals != 0 {
+		for len(r.buf) >= 2 {
+			m.RR = append(m.RR, time.Duration(r.uint16())*time.Second/1024)
+		}
+	}
+
+	if r.err != nil {
+		return heartRateMeasurement{}, fmt.Errorf("could not decode heart rate: %w", r.err)
+	}
+	return m, nil
+}
+
+// heartRateCharacteristic splits the notifications of
+// a heart rate strap in the heart rate and RR intervals.
+type heartRateCharacteristic struct {
+	char bluetoothcharacteristic
+
+	once sync.Once
+	err  error
+
+	hr field
+	rr field
+}
+
+func newHeartRate(char bluetoothcharacteristic) *heartRateCharacteristic {
+	h := &heartRateCharacteristic{char: char}
+	h.hr.source = h
+	h.rr.source = h
+	return h
+}
+
+func (h *heartRateCharacteristic) enable() error {
+	h.once.Do(func() {
+		h.err = h.char.EnableNotifications(h.notify)
+	})
+	return h.err
+}
+
+func (h *heartRateCharacteristic) notify(buf []byte) {
+	m, err := decodeHeartRate(buf)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	// without skin contact the heart rate is meaningless
+	if !m.Contact {
+		return
+	}
+
+	h.hr.WriteValue(m.Hr)
+	for _, rr := range m.RR {
+		h.rr.WriteValue(int(rr.Milliseconds()))
+	}
+}
+
+func verifyHr(device bluetootdevice) (bluetoothcharacteristic, error) {
+	services, err := device.DiscoverServices([]bluetooth.UUID{hrServiceUuid})
+	if err != nil || len(services) != 1 {
+		return nil, fmt.Errorf("Device does not have the heart rate service")
+	}
+
+	char, err := getChar(services[0], hrMeasurementCharUuid)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get heart rate measurement: %w", err)
+	}
+
+	return char, nil
+}
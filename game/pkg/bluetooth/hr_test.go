@@ -0,0 +1,61 @@
+package bluetooth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeHeartRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      []byte
+		expected heartRateMeasurement
+	}{
+		{
+			name:     "8 bit",
+			buf:      []byte{0x00, 0x8c},
+			expected: heartRateMeasurement{Hr: 140, Contact: true},
+		},
+		{
+			name:     "16 bit",
+			buf:      []byte{0x01, 0x2c, 0x01},
+			expected: heartRateMeasurement{Hr: 300, Contact: true},
+		},
+		{
+			name:     "no skin contact",
+			buf:      []byte{0x04, 0x8c},
+			expected: heartRateMeasurement{Hr: 140, Contact: false},
+		},
+		{
+			name: "energy and rr intervals",
+			// 1024/1024s and 512/1024s
+			buf: []byte{0x18, 0x8c, 0x0a, 0x00, 0x00, 0x04, 0x00, 0x02},
+			expected: heartRateMeasurement{
+				Hr:      140,
+				Contact: true,
+				Energy:  10,
+				RR:      []time.Duration{time.Second, 500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := decodeHeartRate(tt.buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeHeartRateTooShort(t *testing.T) {
+	if _, err := decodeHeartRate([]byte{0x01, 0x2c}); err == nil {
+		t.Error("expected an error for a truncated 16 bit heart rate")
+	}
+}
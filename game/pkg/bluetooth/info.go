@@ -0,0 +1,218 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// DeviceInfo is what a device tells about itself when it is connected,
+// see the device information and battery services. The fields it does
+// not report are empty.
+type DeviceInfo struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	Firmware     string `json:"firmware,omitempty"`
+	// Battery is the battery level in %, it is -1
+	// when the device has no battery service.
+	Battery int `json:"battery"`
+}
+
+// readDeviceInfo reads the device information and the battery level.
+// Both services are optional, the battery is nil when it is missing.
+func readDeviceInfo(device bluetootdevice) (DeviceInfo, *battery) {
+	info := DeviceInfo{Battery: -1}
+
+	services, err := device.DiscoverServices([]bluetooth.UUID{deviceInfoServiceUuid})
+	if err == nil && len(services) == 1 {
+		fields := []struct {
+			uuid  bluetooth.UUID
+			value *string
+		}{
+			{manufacturerNameCharUuid, &info.Manufacturer},
+			{modelNumberCharUuid, &info.Model},
+			{serialNumberCharUuid, &info.Serial},
+			{firmwareRevisionCharUuid, &info.Firmware},
+		}
+
+		for _, f := range fields {
+			if char, err := getChar(services[0], f.uuid); err == nil {
+				*f.value = readString(char)
+			}
+		}
+	}
+
+	services, err = device.DiscoverServices([]bluetooth.UUID{batteryServiceUuid})
+	if err != nil || len(services) != 1 {
+		return info, nil
+	}
+
+	char, err := getChar(services[0], batteryLevelCharUuid)
+	if err != nil {
+		return info, nil
+	}
+
+	buf := make([]byte, 1)
+	if n, err := char.Read(buf); err == nil && n == 1 {
+		info.Battery = int(buf[0])
+	}
+	return info, newBattery(char)
+}
+
+// readString reads a characteristic with a UTF-8 string,
+// some devices pad it with zeros.
+func readString(char bluetoothcharacteristic) string {
+	buf := make([]byte, 64)
+	n, err := char.Read(buf)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(buf[:n]), "\x00 ")
+}
+
+// battery passes on the battery level notifications of a device, in %
+type battery struct {
+	char bluetoothcharacteristic
+
+	once sync.Once
+	err  error
+
+	listeners
+}
+
+func newBattery(char bluetoothcharacteristic) *battery {
+	return &battery{char: char}
+}
+
+// ContinuousRead subscribes to the level, it fails
+// when the device does not notify its battery level.
+func (b *battery) ContinuousRead() error {
+	b.once.Do(func() {
+		b.err = b.char.EnableNotifications(b.notify)
+	})
+	return b.err
+}
+
+func (b *battery) Write(int) (int, error) {
+	return 0, errReadOnly
+}
+
+func (b *battery) notify(buf []byte) {
+	if len(buf) == 0 {
+		return
+	}
+	b.WriteValue(int(buf[0]))
+}
+
+// batteries passes on the battery levels of all devices of a
+// session, the source of a sample tells which device it is of.
+type batteries struct {
+	sources []readwriter
+	listeners
+}
+
+func newBatteries(sources ...readwriter) *batteries {
+	b := &batteries{sources: sources}
+	for _, source := range sources {
+		sub, ok := source.Subscribe(context.Background(), 1)
+		if !ok {
+			continue
+		}
+
+		go func() {
+			for sample := range sub.C {
+				b.publish(sample)
+			}
+		}()
+	}
+	return b
+}
+
+func (b *batteries) ContinuousRead() error {
+	var errs []error
+	for _, source := range b.sources {
+		errs = append(errs, source.ContinuousRead())
+	}
+	return errors.Join(errs...)
+}
+
+func (b *batteries) Write(int) (int, error) {
+	return 0, errReadOnly
+}
+
+// BatteryLevels keeps the last battery level of every device of a
+// session by its role. The levels read when connecting are kept for
+// the devices that don't notify them.
+type BatteryLevels struct {
+	mu     sync.Mutex
+	levels map[Role]int
+}
+
+// WatchBatteryLevels follows the battery levels of d until ctx is done
+func WatchBatteryLevels(ctx context.Context, d *Device) *BatteryLevels {
+	b := &BatteryLevels{levels: map[Role]int{}}
+	for role, info := range d.Info {
+		if info.Battery >= 0 {
+			b.levels[role] = info.Battery
+		}
+	}
+
+	if d.Battery == nil {
+		return b
+	}
+
+	sub, ok := d.Battery.Subscribe(ctx, 1)
+	if !ok {
+		return b
+	}
+
+	go func() {
+		for sample := range sub.C {
+			// the source of a battery level is the role of its device
+			for _, role := range roles {
+				if role.String() == sample.Source {
+					b.set(role, sample.Value)
+				}
+			}
+		}
+	}()
+	return b
+}
+
+func (b *BatteryLevels) set(role Role, level int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.levels[role] = level
+}
+
+// Level returns the last battery level of a role, -1 when it is unknown
+func (b *BatteryLevels) Level(role Role) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	level, ok := b.levels[role]
+	if !ok {
+		return -1
+	}
+	return level
+}
+
+// Lowest returns the device with the lowest battery level,
+// the level is -1 when no device reports its battery level.
+func (b *BatteryLevels) Lowest() (Role, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lowest, lowestLevel := Role(0), -1
+	for _, role := range roles {
+		level, ok := b.levels[role]
+		if ok && (lowestLevel < 0 || level < lowestLevel) {
+			lowest, lowestLevel = role, level
+		}
+	}
+	return lowest, lowestLevel
+}
@@ -0,0 +1,95 @@
+package bluetooth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeviceInfo(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	trainer.setBattery(80)
+	d := connectEmulator(t, trainer)
+
+	expected := DeviceInfo{
+		Manufacturer: "go-train",
+		Model:        "KICKR",
+		Serial:       "E1:EC:00:00:00:01",
+		Firmware:     "1.0.0",
+		Battery:      80,
+	}
+	if d.Info[RoleTrainer] != expected {
+		t.Errorf("expected %+v, got %+v", expected, d.Info[RoleTrainer])
+	}
+
+	battery, ok := d.Battery.Subscribe(context.Background(), 1)
+	if !ok {
+		t.Fatal("expected the battery level to be available")
+	}
+
+	trainer.setBattery(15)
+	select {
+	case s := <-battery.C:
+		if s.Value != 15 || s.Unit != UnitPercent || s.Source != "trainer" {
+			t.Errorf("expected 15%% of the trainer, got %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the new battery level")
+	}
+}
+
+func TestDeviceInfoWithoutBattery(t *testing.T) {
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	d := connectEmulator(t, trainer)
+
+	if d.Info[RoleTrainer].Battery != -1 {
+		t.Errorf("expected no battery level, got %d", d.Info[RoleTrainer].Battery)
+	}
+	if d.Battery != nil {
+		t.Error("expected no battery to listen to")
+	}
+}
+
+func TestBatteryLevels(t *testing.T) {
+	strap := newBattery(nil)
+	strap.label(UnitPercent, RoleHrMonitor.String())
+	d := NewDevice(WithBattery(strap))
+	d.Info = map[Role]DeviceInfo{
+		RoleTrainer:    {Battery: 80},
+		RoleHrMonitor:  {Battery: 90},
+		RolePowerMeter: {Battery: -1},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	levels := WatchBatteryLevels(ctx, &d)
+
+	if role, level := levels.Lowest(); role != RoleTrainer || level != 80 {
+		t.Errorf("expected the trainer at 80%%, got %s at %d%%", role, level)
+	}
+
+	// the strap notifies a lower level during the ride
+	strap.WriteValue(30)
+	deadline := time.After(time.Second)
+	for levels.Level(RoleHrMonitor) != 30 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the new level of the strap, got %d%%", levels.Level(RoleHrMonitor))
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if role, level := levels.Lowest(); role != RoleHrMonitor || level != 30 {
+		t.Errorf("expected the strap at 30%%, got %s at %d%%", role, level)
+	}
+	if level := levels.Level(RolePowerMeter); level != -1 {
+		t.Errorf("expected no level for the power meter, got %d%%", level)
+	}
+}
+
+func TestReadStringTrimsPadding(t *testing.T) {
+	char := &emulatedCharacteristic{value: []byte("1.2.3\x00\x00")}
+	if s := readString(char); s != "1.2.3" {
+		t.Errorf("expected 1.2.3, got %q", s)
+	}
+}
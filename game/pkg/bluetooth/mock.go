@@ -1,72 +1,153 @@
 package bluetooth
 
 import (
+	"encoding/json"
 	"fmt"
-	"log/slog"
-	"slices"
-	"strconv"
+	"io"
 	"time"
 )
 
-type mockPowerChar struct {
-	listeners
+// Readings is a range of readings of the mock trainer,
+// both ends are included. The first reading is 0.
+type Readings struct {
+	From int `json:"from"`
+	To   int `json:"to"`
 }
 
-// mock characteristic
-// that is not implemented
+func (r Readings) contains(i int) bool {
+	return i >= r.From && i <= r.To
+}
+
+// MockProfile describes how the rider on the mock trainer rides
+type MockProfile struct {
+	// Power is what the rider pushes when the trainer does not set it, in W
+	Power int `json:"power"`
+	// Cadence in rpm
+	Cadence int `json:"cadence"`
+	// RestingHr is the heart rate without effort, it rises with the
+	// power. There is no heart rate when it is 0.
+	RestingHr int `json:"resting_hr"`
+	// Battery is the battery level in %, the trainer has no battery when it is 0
+	Battery int `json:"battery"`
+
+	// FollowTarget makes the trainer go to the target power of the workout
+	FollowTarget bool `json:"follow_target"`
+	// Lag is how long the trainer takes to reach most of a new target, in s
+	Lag float64 `json:"lag"`
+	// Noise is the standard deviation of the power in W
+	Noise float64 `json:"noise"`
 
-type errorCadenceChar struct {
-	listeners
+	// Interval is the time between two readings in s
+	Interval float64 `json:"interval"`
+	// Stops are the readings in which the rider does not pedal
+	Stops []Readings `json:"stops"`
+	// Dropouts are the readings that get lost
+	Dropouts []Readings `json:"dropouts"`
+	// Disconnects are the readings in which the trainer is out of reach.
+	// When it takes longer than 10s, the trainer is reconnected after.
+	Disconnects []Readings `json:"disconnects"`
 }
 
-func (char *errorCadenceChar) AddListener(c chan int) bool {
-	return false
+// DefaultMockProfile rides 200W, with a stop from the fourth to the tenth reading
+var DefaultMockProfile = MockProfile{
+	Power:    200,
+	Cadence:  90,
+	Lag:      ergLag.Seconds(),
+	Interval: 2,
+	Stops:    []Readings{{From: 3, To: 9}},
 }
 
-func (p *errorCadenceChar) ContinuousRead() error {
-	return fmt.Errorf("Could not read cadence")
+// LoadMockProfile reads a profile in JSON, the
+// fields that are left out keep their default.
+func LoadMockProfile(in io.Reader) (MockProfile, error) {
+	p := DefaultMockProfile
+	if err := json.NewDecoder(in).Decode(&p); err != nil {
+		return MockProfile{}, fmt.Errorf("invalid mock profile: %w", err)
+	}
+	return p, nil
 }
 
-func (p *errorCadenceChar) Write(power int) (int, error) {
-	return 0, fmt.Errorf("Could not write")
+func (p MockProfile) validate() error {
+	if p.Interval <= 0 {
+		return fmt.Errorf("invalid mock profile: interval %v must be positive", p.Interval)
+	}
+	if p.Lag < 0 || p.Noise < 0 {
+		return fmt.Errorf("invalid mock profile: lag and noise can't be negative")
+	}
+	if p.Battery < 0 || p.Battery > 100 {
+		return fmt.Errorf("invalid mock profile: battery %d%% is out of range", p.Battery)
+	}
+	return nil
 }
 
-// ContinuousRead extracts instantaneous power (signed 16-bit integer, little-endian)
-func (p *mockPowerChar) ContinuousRead() error {
-	go func() {
-		i := 0
-		for {
-			// simulate pause
-			if i > 2 && i < 10 {
-				p.WriteValue(0)
-			} else {
-				p.WriteValue(200)
-			}
-			time.Sleep(2 * time.Second)
-			i++
+func inAny(ranges []Readings, i int) bool {
+	for _, r := range ranges {
+		if r.contains(i) {
+			return true
 		}
-	}()
-
-	return nil
+	}
+	return false
 }
 
-func (p *mockPowerChar) AddListener(c chan int) bool {
-	p.listeners.AddListener(c)
-	return true
+// NewMockDevice is a trainer that rides the default profile
+func NewMockDevice() Device {
+	d, err := NewProfileDevice(DefaultMockProfile)
+	if err != nil {
+		panic(err)
+	}
+	return d
 }
 
-func (p *mockPowerChar) Write(power int) (int, error) {
-	slog.Info("Should write " + strconv.Itoa(power) + " to trainer")
-	ep := encode(power)
-	// contains three bytes
-	// add a new byte in between
-	newEp := slices.Insert(ep, 1, byte(0))
-	return decode(newEp), nil
+// NewProfileDevice is a trainer that rides a profile. It is an emulated
+// trainer, so the readings go through the same decoding as the ones of
+// a real trainer. The ride starts when the power is listened to.
+func NewProfileDevice(p MockProfile) (Device, error) {
+	if err := p.validate(); err != nil {
+		return Device{}, err
+	}
+
+	trainer := newEmulatedTrainer("Mock trainer", "E1:EC:00:00:00:01")
+	trainer.restingHr = float64(p.RestingHr)
+	trainer.followTarget = p.FollowTarget
+	trainer.lag = time.Duration(p.Lag * float64(time.Second))
+	trainer.noise = p.Noise
+	if p.Battery > 0 {
+		trainer.setBattery(p.Battery)
+	}
+
+	adapter := newEmulatedAdapter(trainer.emulatedDevice)
+	d, err := connect(adapter, NewConnectOpts(WithScanTimeout(emulatorScanTimeout)))
+	if err != nil {
+		return Device{}, err
+	}
+
+	go rideProfile(trainer, p)
+	return *d, nil
 }
 
-func NewMockDevice() Device {
-	return NewDevice(
-		WithPower(&mockPowerChar{}),
-		WithCadence(&errorCadenceChar{}),
-	)
+// rideProfile moves the trainer every interval as described by the profile
+func rideProfile(trainer *emulatedTrainer, p MockProfile) {
+	<-trainer.power.subscribed()
+
+	interval := time.Duration(p.Interval * float64(time.Second))
+	for i := 0; ; i++ {
+		// the rider stops pedaling when the spin down asks for it
+		cadence := p.Cadence
+		if inAny(p.Stops, i) || trainer.coasting() {
+			cadence = 0
+		}
+		trainer.setRider(float64(p.Power), float64(cadence))
+		trainer.setInReach(!inAny(p.Disconnects, i))
+
+		power, bikeData, status := trainer.advance(interval)
+		if trainer.inReach() && !inAny(p.Dropouts, i) {
+			trainer.power.notify(power)
+			trainer.bikeData.notify(bikeData)
+			if status != nil {
+				trainer.status.notify(status)
+			}
+		}
+
+		time.Sleep(interval)
+	}
 }
@@ -0,0 +1,176 @@
+package bluetooth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockProfile is a fast profile, so the tests don't wait for the readings
+func mockProfile() MockProfile {
+	p := DefaultMockProfile
+	p.Interval = 0.01
+	p.Stops = nil
+	return p
+}
+
+// next returns the next sample of sub
+func next(t *testing.T, sub *Subscription[Sample]) Sample {
+	t.Helper()
+
+	select {
+	case s := <-sub.C:
+		return s
+	case <-time.After(time.Second):
+		t.Fatal("expected a reading of the mock trainer")
+		return Sample{}
+	}
+}
+
+func TestLoadMockProfile(t *testing.T) {
+	p, err := LoadMockProfile(strings.NewReader(`{"power": 150, "resting_hr": 60, "dropouts": [{"from": 1, "to": 2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Power != 150 || p.RestingHr != 60 || len(p.Dropouts) != 1 {
+		t.Errorf("expected the fields of the profile, got %+v", p)
+	}
+	if p.Cadence != DefaultMockProfile.Cadence || p.Interval != DefaultMockProfile.Interval {
+		t.Errorf("expected the fields that are left out to keep their default, got %+v", p)
+	}
+
+	if _, err := LoadMockProfile(strings.NewReader(`{"power": "a lot"}`)); err == nil {
+		t.Error("expected an invalid profile to fail")
+	}
+}
+
+func TestMockProfileInvalid(t *testing.T) {
+	p := mockProfile()
+	p.Interval = 0
+
+	if _, err := NewProfileDevice(p); err == nil {
+		t.Error("expected an interval of 0 to be rejected")
+	}
+}
+
+func TestMockProfileStops(t *testing.T) {
+	p := mockProfile()
+	p.Stops = []Readings{{From: 1, To: 2}}
+
+	d, err := NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cadence, _ := d.Cadence.Subscribe(context.Background(), 10)
+	d.Listen()
+
+	for i, expected := range []int{90, 0, 0, 90} {
+		if c := next(t, cadence); c.Value != expected {
+			t.Errorf("expected %drpm on reading %d, got %d", expected, i, c.Value)
+		}
+	}
+}
+
+func TestMockProfileDropouts(t *testing.T) {
+	p := mockProfile()
+	p.Dropouts = []Readings{{From: 0, To: 4}}
+
+	d, err := NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	power, _ := d.Power.Subscribe(context.Background(), 10)
+	d.Listen()
+
+	start := time.Now()
+	next(t, power)
+	if elapsed := time.Since(start); elapsed < 5*time.Duration(p.Interval*float64(time.Second)) {
+		t.Errorf("expected the first 5 readings to get lost, got one after %s", elapsed)
+	}
+}
+
+func TestMockProfileDisconnects(t *testing.T) {
+	// the trainer is out of reach for longer than it may be silent
+	defer func(previous time.Duration) { disconnectAfter = previous }(disconnectAfter)
+	disconnectAfter = 50 * time.Millisecond
+
+	p := mockProfile()
+	p.Disconnects = []Readings{{From: 2, To: 20}}
+
+	d, err := NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, ok := d.SubscribeConnection(context.Background(), 10)
+	if !ok {
+		t.Fatal("expected the mock trainer to be watched")
+	}
+	d.Listen()
+
+	for _, expected := range []ConnectionState{StateReconnecting, StateConnected} {
+		deadline := time.After(5 * time.Second)
+		for state := ConnectionState(-1); state != expected; {
+			select {
+			case e := <-events.C:
+				state = e.State
+			case <-deadline:
+				t.Fatalf("expected the trainer to be %s", expected)
+			}
+		}
+	}
+}
+
+func TestMockProfileFollowTarget(t *testing.T) {
+	p := mockProfile()
+	p.Lag = 0
+
+	d, err := NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	power, _ := d.Power.Subscribe(context.Background(), 10)
+	d.Listen()
+
+	if _, err := d.Power.Write(300); err != nil {
+		t.Fatal(err)
+	}
+	next(t, power)
+	if w := next(t, power); w.Value != 200 {
+		t.Errorf("expected the rider to keep riding 200W, got %dW", w.Value)
+	}
+
+	p.FollowTarget = true
+	d, err = NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	power, _ = d.Power.Subscribe(context.Background(), 10)
+	d.Listen()
+
+	if _, err := d.Power.Write(300); err != nil {
+		t.Fatal(err)
+	}
+	next(t, power)
+	if w := next(t, power); w.Value != 300 {
+		t.Errorf("expected the trainer to follow the target of 300W, got %dW", w.Value)
+	}
+}
+
+func TestMockProfileHr(t *testing.T) {
+	p := mockProfile()
+	p.RestingHr = 60
+
+	d, err := NewProfileDevice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr, _ := d.Hr.Subscribe(context.Background(), 10)
+	d.Listen()
+
+	first := next(t, hr)
+	if first.Value < 60 || first.Value > 70 {
+		t.Errorf("expected the heart rate to start close to 60bpm, got %d", first.Value)
+	}
+}
@@ -0,0 +1,258 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: bluetooth.go
+//
+// Generated by this command:
+//
+//	mockgen -source=bluetooth.go -destination=mocks_test.go -package=bluetooth -self_package=overlay/pkg/bluetooth
+//
+
+// Package bluetooth is a generated GoMock package.
+package bluetooth
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+	bluetooth "tinygo.org/x/bluetooth"
+)
+
+// Mockbluetoothadapter is a mock of bluetoothadapter interface.
+type Mockbluetoothadapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockbluetoothadapterMockRecorder
+	isgomock struct{}
+}
+
+// MockbluetoothadapterMockRecorder is the mock recorder for Mockbluetoothadapter.
+type MockbluetoothadapterMockRecorder struct {
+	mock *Mockbluetoothadapter
+}
+
+// NewMockbluetoothadapter creates a new mock instance.
+func NewMockbluetoothadapter(ctrl *gomock.Controller) *Mockbluetoothadapter {
+	mock := &Mockbluetoothadapter{ctrl: ctrl}
+	mock.recorder = &MockbluetoothadapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbluetoothadapter) EXPECT() *MockbluetoothadapterMockRecorder {
+	return m.recorder
+}
+
+// Connect mocks base method.
+func (m *Mockbluetoothadapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (bluetootdevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect", address, params)
+	ret0, _ := ret[0].(bluetootdevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockbluetoothadapterMockRecorder) Connect(address, params any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*Mockbluetoothadapter)(nil).Connect), address, params)
+}
+
+// Scan mocks base method.
+func (m *Mockbluetoothadapter) Scan(callback func(*bluetooth.Adapter, bluetooth.ScanResult)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", callback)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockbluetoothadapterMockRecorder) Scan(callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*Mockbluetoothadapter)(nil).Scan), callback)
+}
+
+// StopScan mocks base method.
+func (m *Mockbluetoothadapter) StopScan() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopScan")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopScan indicates an expected call of StopScan.
+func (mr *MockbluetoothadapterMockRecorder) StopScan() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopScan", reflect.TypeOf((*Mockbluetoothadapter)(nil).StopScan))
+}
+
+// Mockbluetootdevice is a mock of bluetootdevice interface.
+type Mockbluetootdevice struct {
+	ctrl     *gomock.Controller
+	recorder *MockbluetootdeviceMockRecorder
+	isgomock struct{}
+}
+
+// MockbluetootdeviceMockRecorder is the mock recorder for Mockbluetootdevice.
+type MockbluetootdeviceMockRecorder struct {
+	mock *Mockbluetootdevice
+}
+
+// NewMockbluetootdevice creates a new mock instance.
+func NewMockbluetootdevice(ctrl *gomock.Controller) *Mockbluetootdevice {
+	mock := &Mockbluetootdevice{ctrl: ctrl}
+	mock.recorder = &MockbluetootdeviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbluetootdevice) EXPECT() *MockbluetootdeviceMockRecorder {
+	return m.recorder
+}
+
+// DiscoverServices mocks base method.
+func (m *Mockbluetootdevice) DiscoverServices(uuids []bluetooth.UUID) ([]bluetoothservice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverServices", uuids)
+	ret0, _ := ret[0].([]bluetoothservice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverServices indicates an expected call of DiscoverServices.
+func (mr *MockbluetootdeviceMockRecorder) DiscoverServices(uuids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverServices", reflect.TypeOf((*Mockbluetootdevice)(nil).DiscoverServices), uuids)
+}
+
+// Mockbluetoothservice is a mock of bluetoothservice interface.
+type Mockbluetoothservice struct {
+	ctrl     *gomock.Controller
+	recorder *MockbluetoothserviceMockRecorder
+	isgomock struct{}
+}
+
+// MockbluetoothserviceMockRecorder is the mock recorder for Mockbluetoothservice.
+type MockbluetoothserviceMockRecorder struct {
+	mock *Mockbluetoothservice
+}
+
+// NewMockbluetoothservice creates a new mock instance.
+func NewMockbluetoothservice(ctrl *gomock.Controller) *Mockbluetoothservice {
+	mock := &Mockbluetoothservice{ctrl: ctrl}
+	mock.recorder = &MockbluetoothserviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbluetoothservice) EXPECT() *MockbluetoothserviceMockRecorder {
+	return m.recorder
+}
+
+// DiscoverCharacteristics mocks base method.
+func (m *Mockbluetoothservice) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]bluetoothcharacteristic, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverCharacteristics", uuids)
+	ret0, _ := ret[0].([]bluetoothcharacteristic)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverCharacteristics indicates an expected call of DiscoverCharacteristics.
+func (mr *MockbluetoothserviceMockRecorder) DiscoverCharacteristics(uuids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverCharacteristics", reflect.TypeOf((*Mockbluetoothservice)(nil).DiscoverCharacteristics), uuids)
+}
+
+// UUID mocks base method.
+func (m *Mockbluetoothservice) UUID() bluetooth.UUID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UUID")
+	ret0, _ := ret[0].(bluetooth.UUID)
+	return ret0
+}
+
+// UUID indicates an expected call of UUID.
+func (mr *MockbluetoothserviceMockRecorder) UUID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UUID", reflect.TypeOf((*Mockbluetoothservice)(nil).UUID))
+}
+
+// Mockbluetoothcharacteristic is a mock of bluetoothcharacteristic interface.
+type Mockbluetoothcharacteristic struct {
+	ctrl     *gomock.Controller
+	recorder *MockbluetoothcharacteristicMockRecorder
+	isgomock struct{}
+}
+
+// MockbluetoothcharacteristicMockRecorder is the mock recorder for Mockbluetoothcharacteristic.
+type MockbluetoothcharacteristicMockRecorder struct {
+	mock *Mockbluetoothcharacteristic
+}
+
+// NewMockbluetoothcharacteristic creates a new mock instance.
+func NewMockbluetoothcharacteristic(ctrl *gomock.Controller) *Mockbluetoothcharacteristic {
+	mock := &Mockbluetoothcharacteristic{ctrl: ctrl}
+	mock.recorder = &MockbluetoothcharacteristicMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbluetoothcharacteristic) EXPECT() *MockbluetoothcharacteristicMockRecorder {
+	return m.recorder
+}
+
+// EnableNotifications mocks base method.
+func (m *Mockbluetoothcharacteristic) EnableNotifications(callback func([]byte)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableNotifications", callback)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableNotifications indicates an expected call of EnableNotifications.
+func (mr *MockbluetoothcharacteristicMockRecorder) EnableNotifications(callback any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableNotifications", reflect.TypeOf((*Mockbluetoothcharacteristic)(nil).EnableNotifications), callback)
+}
+
+// Read mocks base method.
+func (m *Mockbluetoothcharacteristic) Read(data []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", data)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockbluetoothcharacteristicMockRecorder) Read(data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*Mockbluetoothcharacteristic)(nil).Read), data)
+}
+
+// UUID mocks base method.
+func (m *Mockbluetoothcharacteristic) UUID() bluetooth.UUID {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UUID")
+	ret0, _ := ret[0].(bluetooth.UUID)
+	return ret0
+}
+
+// UUID indicates an expected call of UUID.
+func (mr *MockbluetoothcharacteristicMockRecorder) UUID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UUID", reflect.TypeOf((*Mockbluetoothcharacteristic)(nil).UUID))
+}
+
+// Write mocks base method.
+func (m *Mockbluetoothcharacteristic) Write(p []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", p)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockbluetoothcharacteristicMockRecorder) Write(p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*Mockbluetoothcharacteristic)(nil).Write), p)
+}
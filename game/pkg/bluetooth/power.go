@@ -2,43 +2,75 @@ package bluetooth
 
 import (
 	"encoding/binary"
-
-	"tinygo.org/x/bluetooth"
+	"log/slog"
+	"sync"
 )
 
-type listeners struct {
-	listeners []chan int
-}
+// powerCharacteristic passes on the power of the cycling power
+// measurement. The speed and cadence come from the revolutions,
+// when the device measures them.
+type powerCharacteristic struct {
+	readPwr bluetoothcharacteristic
+	control *controlPoint
 
-func (l *listeners) AddListener(c chan int) bool {
-	if l.listeners == nil {
-		l.listeners = make([]chan int, 0)
-	}
+	once sync.Once
+	err  error
 
-	l.listeners = append(l.listeners, c)
-	return true
+	wheel revolutionCounter
+	crank revolutionCounter
+
+	speed   field
+	cadence field
+
+	listeners
 }
 
-func (l *listeners) WriteValue(v int) {
-	for _, listener := range l.listeners {
-		listener <- v
+func newPower(char bluetoothcharacteristic, control *controlPoint) *powerCharacteristic {
+	p := &powerCharacteristic{
+		readPwr: char,
+		control: control,
+		wheel:   revolutionCounter{revBits: 32, ticksPerSecond: 2048},
+		crank:   revolutionCounter{revBits: 16, ticksPerSecond: 1024},
 	}
+	p.speed.source = p
+	p.cadence.source = p
+	return p
 }
 
-type powerCharacteristic struct {
-	readPwr  *bluetooth.DeviceCharacteristic
-	writePwr *bluetooth.DeviceCharacteristic
-
-	listeners
+// ContinuousRead subscribes to the power measurement
+func (p *powerCharacteristic) ContinuousRead() error {
+	return p.enable()
 }
 
-// ContinuousRead extracts instantaneous power (signed 16-bit integer, little-endian)
-func (p *powerCharacteristic) ContinuousRead() error {
-	err := p.readPwr.EnableNotifications(func(buf []byte) {
-		p.listeners.WriteValue(decode(buf))
+// enable subscribes to the notifications, this only happens
+// once for the power, speed and cadence together.
+func (p *powerCharacteristic) enable() error {
+	p.once.Do(func() {
+		p.err = p.readPwr.EnableNotifications(p.notify)
 	})
+	return p.err
+}
 
-	return err
+func (p *powerCharacteristic) notify(buf []byte) {
+	m, err := decodeCyclingPower(buf)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	p.WriteValue(m.Power)
+
+	if m.has(cpWheelRevolutions) {
+		if rpm, ok := p.wheel.update(m.WheelRevolutions, m.WheelEventTime); ok {
+			// in m/h
+			p.speed.WriteValue(int(rpm * wheelCircumference * 60))
+		}
+	}
+	if m.has(cpCrankRevolutions) {
+		if rpm, ok := p.crank.update(uint32(m.CrankRevolutions), m.CrankEventTime); ok {
+			p.cadence.WriteValue(int(rpm))
+		}
+	}
 }
 
 // for writing power I first need to get permission to do so
@@ -49,8 +81,20 @@ func (p *powerCharacteristic) ContinuousRead() error {
 // When the Set Target Power Op Code is written to the Fitness Machine Control Point and the Result Code
 // is ‘Success’, the Server shall set the target power to the value sent as a Parameter.
 // see page 74 to see how the interaction works
+//
+// Write waits until the trainer accepted the target power, a rejected
+// target is returned as a *ControlPointError.
 func (p *powerCharacteristic) Write(power int) (int, error) {
-	return p.writePwr.Write(encode(power))
+	// power meters only measure power
+	if p.control == nil {
+		return 0, errReadOnly
+	}
+
+	_, err := p.control.executeWithControl(encode(power))
+	if err != nil {
+		return 0, err
+	}
+	return power, nil
 }
 
 func encode(power int) []byte {
@@ -58,22 +102,8 @@ func encode(power int) []byte {
 		power = power * -1
 	}
 
-	data := []byte{0x05} // opcode for setting power
+	data := []byte{opSetTargetPower}
 	// since power is postivie this shouldn't matter
 	data = binary.LittleEndian.AppendUint16(data, uint16(power))
 	return data
 }
-
-func decode(buf []byte) int {
-	buf = buf[2:4]
-	power := binary.LittleEndian.Uint16(buf)
-	return int(power)
-}
-
-// requestControl initiates the procedure
-// to request control over the fitness machine
-func (p *powerCharacteristic) requestControl() error {
-	data := []byte{0x00}
-	_, err := p.writePwr.Write(data)
-	return err
-}
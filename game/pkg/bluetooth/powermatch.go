@@ -0,0 +1,150 @@
+package bluetooth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+)
+
+const (
+	// powerMatchWindow is the number of power meter readings
+	// that are averaged before the correction is adjusted
+	powerMatchWindow = 10
+	// maxPowerCorrection bounds the correction, a bigger offset
+	// means something is wrong with one of the devices
+	maxPowerCorrection = 0.15
+)
+
+// powerMatch steers the trainer with the readings of a power meter.
+// Trainers measure power differently than e.g. power pedals, so the
+// target sent to the trainer is corrected until the power meter reads
+// the target. The readings are passed on as is.
+type powerMatch struct {
+	readwriter
+	trainer readwriter
+
+	mu       sync.Mutex
+	readings []int
+	target   int
+	sent     int
+	// factor is what the target is multiplied with
+	factor float64
+
+	// the offsets between the power meter and the target
+	offsetSum   float64
+	offsetCount int
+}
+
+// newPowerMatch passes on the readings of power, while writing the
+// corrected targets to trainer by listening to the power meter.
+func newPowerMatch(power readwriter, trainer readwriter, meter readwriter) *powerMatch {
+	p := &powerMatch{
+		readwriter: power,
+		trainer:    trainer,
+		factor:     1,
+	}
+
+	if sub, ok := meter.Subscribe(context.Background(), powerMatchWindow); ok {
+		go func() {
+			for v := range sub.C {
+				p.measure(v.Value)
+			}
+		}()
+	}
+
+	return p
+}
+
+// Write sets a new target, corrected with the current offset
+func (p *powerMatch) Write(target int) (int, error) {
+	p.mu.Lock()
+	if target != p.target {
+		// the readings of the previous target mean nothing for this one
+		p.readings = nil
+	}
+	p.target = target
+	p.sent = p.corrected()
+	sent := p.sent
+	p.mu.Unlock()
+
+	_, err := p.trainer.Write(sent)
+	if err != nil {
+		return 0, err
+	}
+	return target, nil
+}
+
+// suspend stops correcting the target until the next Write, the
+// trainer is not in ERG mode so there is nothing to correct.
+func (p *powerMatch) suspend() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.target = 0
+	p.readings = nil
+}
+
+func (p *powerMatch) corrected() int {
+	return int(math.Round(float64(p.target) * p.factor))
+}
+
+// measure adjusts the correction once enough readings of the power
+// meter came in, and sends the new target to the trainer.
+func (p *powerMatch) measure(power int) {
+	p.mu.Lock()
+
+	p.readings = append(p.readings, power)
+	if p.target <= 0 || len(p.readings) < powerMatchWindow {
+		p.mu.Unlock()
+		return
+	}
+
+	avg := mean(p.readings)
+	// wait for new readings with the new correction
+	p.readings = nil
+
+	// the rider stopped pedaling
+	if avg == 0 {
+		p.mu.Unlock()
+		return
+	}
+
+	offset := avg/float64(p.target) - 1
+	p.offsetSum += offset
+	p.offsetCount++
+	average := p.offsetSum / float64(p.offsetCount)
+
+	p.factor = clamp(p.factor*float64(p.target)/avg, 1-maxPowerCorrection, 1+maxPowerCorrection)
+	corrected := p.corrected()
+	changed := corrected != p.sent
+	p.sent = corrected
+	target := p.target
+	p.mu.Unlock()
+
+	slog.Info(fmt.Sprintf(
+		"Power match: power meter reads %.0fW for %dW (%+.1f%%, average %+.1f%%), sending %dW",
+		avg, target, offset*100, average*100, corrected,
+	))
+
+	if !changed {
+		return
+	}
+
+	if _, err := p.trainer.Write(corrected); err != nil {
+		slog.Error("could not write matched power: " + err.Error())
+	}
+}
+
+func mean(values []int) float64 {
+	var sum int
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
+
+func clamp(v float64, low float64, high float64) float64 {
+	return math.Max(low, math.Min(high, v))
+}
@@ -0,0 +1,65 @@
+package bluetooth
+
+import "testing"
+
+func newTestPowerMatch() (*powerMatch, *fakeSource) {
+	trainer := &fakeSource{}
+	p := newPowerMatch(trainer, trainer, &fakeSource{})
+	return p, trainer
+}
+
+func measureWindow(p *powerMatch, power int) {
+	for range powerMatchWindow {
+		p.measure(power)
+	}
+}
+
+func TestPowerMatch(t *testing.T) {
+	p, trainer := newTestPowerMatch()
+
+	n, err := p.Write(200)
+	if err != nil || n != 200 {
+		t.Fatalf("expected the target to be written, got %d, %v", n, err)
+	}
+
+	// the power meter reads 5% more than the trainer
+	measureWindow(p, 210)
+	if last := trainer.written[len(trainer.written)-1]; last != 190 {
+		t.Errorf("expected the trainer to get 190W, got %d", last)
+	}
+
+	// the correction is kept for the next target
+	_, _ = p.Write(300)
+	if last := trainer.written[len(trainer.written)-1]; last != 286 {
+		t.Errorf("expected the trainer to get 286W, got %d", last)
+	}
+}
+
+func TestPowerMatchBounded(t *testing.T) {
+	p, trainer := newTestPowerMatch()
+	_, _ = p.Write(200)
+
+	measureWindow(p, 400)
+	if last := trainer.written[len(trainer.written)-1]; last != 170 {
+		t.Errorf("expected the correction to be bounded at 170W, got %d", last)
+	}
+}
+
+func TestPowerMatchIgnoresStops(t *testing.T) {
+	p, trainer := newTestPowerMatch()
+	_, _ = p.Write(200)
+
+	measureWindow(p, 0)
+	if len(trainer.written) != 1 {
+		t.Errorf("expected no correction without pedaling, got %v", trainer.written)
+	}
+}
+
+func TestPowerMatchWithoutTarget(t *testing.T) {
+	p, trainer := newTestPowerMatch()
+
+	measureWindow(p, 200)
+	if len(trainer.written) != 0 {
+		t.Errorf("expected nothing to be written without a target, got %v", trainer.written)
+	}
+}
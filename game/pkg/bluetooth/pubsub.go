@@ -0,0 +1,162 @@
+package bluetooth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Subscription receives the values published to a hub. Publishing
+// never blocks: when the buffer is full the oldest value is dropped,
+// so a slow subscriber always gets the latest values.
+type Subscription[T any] struct {
+	// C is closed when the subscription ends
+	C <-chan T
+	c chan T
+
+	dropped atomic.Uint64
+	owner   *hub[T]
+	// stop releases the context, it is guarded by the owner
+	stop func() bool
+	once sync.Once
+}
+
+// Dropped returns the number of values the subscriber was too slow for
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops the subscription and closes C
+func (s *Subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		s.owner.remove(s)
+	})
+}
+
+// send passes on a value without blocking, it drops
+// the oldest value when the subscriber is behind.
+func (s *Subscription[T]) send(v T) {
+	for {
+		select {
+		case s.c <- v:
+			return
+		default:
+		}
+
+		select {
+		case <-s.c:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// hub fans out values to its subscribers
+type hub[T any] struct {
+	mu   sync.Mutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// Subscribe returns a subscription that buffers up to size values.
+// It ends when ctx is done or when it is unsubscribed.
+func (h *hub[T]) Subscribe(ctx context.Context, size int) (*Subscription[T], bool) {
+	c := make(chan T, max(size, 1))
+	s := &Subscription[T]{C: c, c: c, owner: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subs == nil {
+		h.subs = map[*Subscription[T]]struct{}{}
+	}
+	h.subs[s] = struct{}{}
+	s.stop = context.AfterFunc(ctx, s.Unsubscribe)
+
+	return s, true
+}
+
+// publish passes a value on to all subscribers, it never blocks
+func (h *hub[T]) publish(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs {
+		s.send(v)
+	}
+}
+
+// close ends all subscriptions, e.g. when the source is gone for good
+func (h *hub[T]) close() {
+	h.mu.Lock()
+	subs := make([]*Subscription[T], 0, len(h.subs))
+	for s := range h.subs {
+		subs = append(subs, s)
+	}
+	h.mu.Unlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+}
+
+func (h *hub[T]) remove(s *Subscription[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+
+	delete(h.subs, s)
+	close(s.c)
+	s.stop()
+}
+
+// listeners fans out the samples of a characteristic to its subscribers
+type listeners struct {
+	hub[Sample]
+
+	// unit and source are stamped on the values that are written
+	unit   Unit
+	source string
+}
+
+func (l *listeners) label(unit Unit, source string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.unit, l.source = unit, source
+}
+
+// AddListener passes every value on to a channel of the caller, without
+// the rest of the sample. A slow listener only misses values, it does
+// not stall the others.
+//
+// A listener can't be removed, the goroutine that passes the values on
+// lives as long as the characteristic and blocks when c is not read.
+// It is kept for tests, Subscribe is what stops with its context.
+func (l *listeners) AddListener(c chan int) bool {
+	s, ok := l.Subscribe(context.Background(), 1)
+	if !ok {
+		return false
+	}
+
+	go func() {
+		for v := range s.C {
+			c <- v.Value
+		}
+	}()
+
+	return true
+}
+
+// WriteValue publishes a value that was just read to all subscribers
+func (l *listeners) WriteValue(v int) {
+	now := time.Now()
+
+	l.mu.Lock()
+	sample := Sample{Value: v, Unit: l.unit, Source: l.source, Time: now}
+	l.mu.Unlock()
+
+	l.publish(sample)
+}
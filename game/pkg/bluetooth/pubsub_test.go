@@ -0,0 +1,156 @@
+package bluetooth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	var l listeners
+	sub, ok := l.Subscribe(context.Background(), 2)
+	if !ok {
+		t.Fatal("expected a subscription")
+	}
+
+	l.WriteValue(1)
+	l.WriteValue(2)
+	if v := <-sub.C; v.Value != 1 {
+		t.Errorf("expected 1, got %d", v.Value)
+	}
+	if v := <-sub.C; v.Value != 2 {
+		t.Errorf("expected 2, got %d", v.Value)
+	}
+}
+
+func TestWriteValueStampsSample(t *testing.T) {
+	var l listeners
+	l.label(UnitBpm, "heart rate monitor")
+	sub, _ := l.Subscribe(context.Background(), 1)
+
+	before := time.Now()
+	l.WriteValue(120)
+
+	sample := <-sub.C
+	if sample.Value != 120 || sample.Unit != UnitBpm || sample.Source != "heart rate monitor" {
+		t.Errorf("expected 120bpm of the heart rate monitor, got %+v", sample)
+	}
+	if sample.Time.Before(before) || sample.Time.After(time.Now()) {
+		t.Errorf("expected the time the value was written, got %s", sample.Time)
+	}
+}
+
+func TestSlowSubscriberGetsLatest(t *testing.T) {
+	var l listeners
+	slow, _ := l.Subscribe(context.Background(), 1)
+
+	// nobody reads, publishing should not block
+	for v := range 10 {
+		l.WriteValue(v)
+	}
+
+	if v := <-slow.C; v.Value != 9 {
+		t.Errorf("expected the latest value 9, got %d", v.Value)
+	}
+	if slow.Dropped() != 9 {
+		t.Errorf("expected 9 dropped values, got %d", slow.Dropped())
+	}
+}
+
+func TestSlowSubscriberDoesNotStallOthers(t *testing.T) {
+	var l listeners
+	_, _ = l.Subscribe(context.Background(), 1)
+	fast, _ := l.Subscribe(context.Background(), 1)
+
+	received := make(chan int)
+	go func() {
+		for v := range fast.C {
+			received <- v.Value
+		}
+	}()
+
+	for v := range 3 {
+		l.WriteValue(v)
+		select {
+		case actual := <-received:
+			if actual != v {
+				t.Errorf("expected %d, got %d", v, actual)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the fast subscriber was stalled")
+		}
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	var l listeners
+	sub, _ := l.Subscribe(context.Background(), 1)
+
+	sub.Unsubscribe()
+	// unsubscribing twice is fine
+	sub.Unsubscribe()
+
+	l.WriteValue(1)
+	if _, open := <-sub.C; open {
+		t.Error("expected the channel to be closed")
+	}
+}
+
+func TestSubscribeContextCancel(t *testing.T) {
+	var l listeners
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, _ := l.Subscribe(ctx, 1)
+
+	cancel()
+	select {
+	case _, open := <-sub.C:
+		if open {
+			t.Error("expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the subscription did not end with its context")
+	}
+}
+
+func TestSubscribeConcurrently(t *testing.T) {
+	var l listeners
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sub, _ := l.Subscribe(ctx, 1)
+			for range 100 {
+				select {
+				case <-sub.C:
+				default:
+				}
+			}
+			sub.Unsubscribe()
+		}()
+
+		go func() {
+			defer wg.Done()
+			for v := range 100 {
+				l.WriteValue(v)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestAddListener(t *testing.T) {
+	var l listeners
+	c := make(chan int)
+	l.AddListener(c)
+
+	l.WriteValue(200)
+	if v := <-c; v != 200 {
+		t.Errorf("expected 200, got %d", v)
+	}
+}
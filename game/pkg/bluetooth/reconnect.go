@@ -0,0 +1,389 @@
+package bluetooth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionState tells whether a device can be reached
+type ConnectionState int
+
+const (
+	StateConnected ConnectionState = iota
+	StateReconnecting
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return fmt.Sprintf("state %d", int(s))
+	}
+}
+
+// ConnectionEvent is sent when the connection with a device changes.
+// While reconnecting, Err holds why the last attempt failed.
+type ConnectionEvent struct {
+	Role    Role
+	State   ConnectionState
+	Attempt int
+	Err     error
+}
+
+// disconnectAfter is how long the trainer can stay silent
+// before it is considered disconnected. Trainers notify
+// their power every second, even when it's 0.
+var disconnectAfter = 10 * time.Second
+
+// maxReconnectDelay is the longest wait between two attempts
+const maxReconnectDelay = 5 * time.Second
+
+// commands keeps the last command that was sent to the trainer, a
+// target power or a control request. It is sent again after a
+// reconnect, while the trainer is offline it is only kept.
+type commands struct {
+	mu      sync.Mutex
+	last    func() error
+	offline bool
+}
+
+// send sends the command unless the trainer is offline
+func (c *commands) send(command func() error) error {
+	c.mu.Lock()
+	c.last = command
+	offline := c.offline
+	c.mu.Unlock()
+
+	if offline {
+		return nil
+	}
+	return command()
+}
+
+func (c *commands) setOffline(offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offline = offline
+}
+
+// resend sends the last command again
+func (c *commands) resend() {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+
+	if last == nil {
+		return
+	}
+
+	if err := last(); err != nil {
+		slog.Error("could not restore the last command: " + err.Error())
+	}
+}
+
+// relay passes on the readings of a source. The source is
+// replaced after a reconnect, the listeners stay the same.
+type relay struct {
+	mu     sync.Mutex
+	source readwriter
+	sub    *Subscription[Sample]
+	// commands keeps the writes to be sent again after a reconnect,
+	// without it the writes are only passed on.
+	commands *commands
+
+	available bool
+	seen      func()
+
+	listeners
+}
+
+func newRelay(source readwriter, seen func()) *relay {
+	r := &relay{seen: seen}
+	r.available = r.bind(source)
+	return r
+}
+
+// bind replaces the source, the previous source is unsubscribed from
+func (r *relay) bind(source readwriter) bool {
+	sub, ok := source.Subscribe(context.Background(), 1)
+
+	r.mu.Lock()
+	previous := r.sub
+	r.source, r.sub = source, sub
+	r.mu.Unlock()
+
+	if previous != nil {
+		previous.Unsubscribe()
+	}
+
+	if !ok {
+		return false
+	}
+
+	go func() {
+		for v := range sub.C {
+			r.seen()
+			r.publish(v)
+		}
+	}()
+
+	return true
+}
+
+func (r *relay) current() readwriter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.source
+}
+
+func (r *relay) AddListener(c chan int) bool {
+	if !r.available {
+		return false
+	}
+	return r.listeners.AddListener(c)
+}
+
+func (r *relay) Subscribe(ctx context.Context, size int) (*Subscription[Sample], bool) {
+	if !r.available {
+		return nil, false
+	}
+	return r.listeners.Subscribe(ctx, size)
+}
+
+func (r *relay) ContinuousRead() error {
+	return r.current().ContinuousRead()
+}
+
+// Write writes to the current source
+func (r *relay) Write(v int) (int, error) {
+	if r.commands == nil {
+		return r.current().Write(v)
+	}
+
+	err := r.commands.send(func() error {
+		_, err := r.current().Write(v)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// relayControl passes control requests on to the current trainer
+type relayControl struct {
+	mu       sync.Mutex
+	control  Controller
+	commands *commands
+}
+
+func (c *relayControl) bind(control Controller) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.control = control
+}
+
+func (c *relayControl) current() Controller {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.control
+}
+
+func (c *relayControl) SetSimulation(p SimulationParams) error {
+	return c.commands.send(func() error {
+		return c.current().SetSimulation(p)
+	})
+}
+
+func (c *relayControl) SetResistance(level float64) error {
+	return c.commands.send(func() error {
+		return c.current().SetResistance(level)
+	})
+}
+
+func (c *relayControl) StartSpinDown() (SpinDownTarget, error) {
+	return c.current().StartSpinDown()
+}
+
+// reconnector watches the trainer and reconnects when it stays silent.
+// After a reconnect the notifications are subscribed to again, control
+// is requested and the last command is sent again.
+type reconnector struct {
+	connect func() (Device, error)
+	timeout time.Duration
+	delay   time.Duration
+
+	lastSeen atomic.Int64
+
+	power       *relay
+	speed       *relay
+	cadence     *relay
+	resistance  *relay
+	hr          *relay
+	elapsedTime *relay
+	battery     *relay
+	control     *relayControl
+	status      *statusCharacteristic
+	// trainerStatus is the status of the connected trainer,
+	// it is forwarded to status.
+	trainerStatus *statusCharacteristic
+	commands      *commands
+
+	// events passes on the connection events
+	events hub[ConnectionEvent]
+}
+
+// watch wraps the readwriters of a trainer so they survive
+// a reconnect, connect is used to connect to it again.
+func watch(d Device, connect func() (Device, error)) (Device, *reconnector) {
+	r := &reconnector{
+		connect:  connect,
+		timeout:  disconnectAfter,
+		delay:    time.Second,
+		commands: &commands{},
+	}
+	r.seen()
+
+	relayOf := func(rw readwriter) (*relay, readwriter) {
+		if rw == nil {
+			return nil, nil
+		}
+		rel := newRelay(rw, r.seen)
+		return rel, rel
+	}
+
+	r.power, d.Power = relayOf(d.Power)
+	if r.power != nil {
+		r.power.commands = r.commands
+	}
+	r.speed, d.Speed = relayOf(d.Speed)
+	r.cadence, d.Cadence = relayOf(d.Cadence)
+	r.resistance, d.Resistance = relayOf(d.Resistance)
+	r.hr, d.Hr = relayOf(d.Hr)
+	r.elapsedTime, d.ElapsedTime = relayOf(d.ElapsedTime)
+	r.battery, d.Battery = relayOf(d.Battery)
+
+	if d.Control != nil {
+		r.control = &relayControl{control: d.Control, commands: r.commands}
+		d.Control = r.control
+	}
+
+	if d.status != nil {
+		r.status = &statusCharacteristic{}
+		r.trainerStatus = d.status
+		forwardStatus(d.status, r.status)
+		d.status = r.status
+	}
+
+	d.connection = r
+	return d, r
+}
+
+// forwardStatus passes the status events of a trainer on to another
+func forwardStatus(from *statusCharacteristic, to *statusCharacteristic) {
+	sub, _ := from.Subscribe(context.Background(), 1)
+	go func() {
+		for e := range sub.C {
+			to.publish(e)
+		}
+	}()
+}
+
+func (r *reconnector) seen() {
+	r.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (r *reconnector) silentFor() time.Duration {
+	return time.Since(time.Unix(0, r.lastSeen.Load()))
+}
+
+// run checks the trainer until done is closed
+func (r *reconnector) run(done <-chan struct{}) {
+	ticker := time.NewTicker(r.timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if r.silentFor() >= r.timeout {
+				r.reconnect()
+			}
+		}
+	}
+}
+
+func (r *reconnector) reconnect() {
+	slog.Info("Lost connection with the trainer, reconnecting...")
+	r.events.publish(ConnectionEvent{Role: RoleTrainer, State: StateReconnecting})
+
+	// don't wait for a trainer that is gone, the last command is sent after the reconnect
+	r.commands.setOffline(true)
+
+	for attempt := 1; ; attempt++ {
+		d, err := r.connect()
+		if err == nil {
+			r.bind(d)
+			break
+		}
+
+		slog.Info(fmt.Sprintf("Reconnect attempt %d failed: %s", attempt, err))
+		r.events.publish(ConnectionEvent{Role: RoleTrainer, State: StateReconnecting, Attempt: attempt, Err: err})
+		time.Sleep(min(time.Duration(attempt)*r.delay, maxReconnectDelay))
+	}
+
+	r.seen()
+	slog.Info("Reconnected with the trainer")
+	r.events.publish(ConnectionEvent{Role: RoleTrainer, State: StateConnected})
+}
+
+// bind moves the relays to the readwriters of the reconnected trainer
+func (r *reconnector) bind(d Device) {
+	fields := []struct {
+		relay  *relay
+		source readwriter
+	}{
+		{r.power, d.Power},
+		{r.speed, d.Speed},
+		{r.cadence, d.Cadence},
+		{r.resistance, d.Resistance},
+		{r.hr, d.Hr},
+		{r.elapsedTime, d.ElapsedTime},
+		{r.battery, d.Battery},
+	}
+
+	for _, f := range fields {
+		if f.relay == nil || f.source == nil {
+			continue
+		}
+
+		f.relay.bind(f.source)
+		if err := f.source.ContinuousRead(); err != nil {
+			slog.Error(err.Error())
+		}
+	}
+
+	if r.control != nil && d.Control != nil {
+		r.control.bind(d.Control)
+	}
+
+	if r.status != nil && d.status != nil {
+		// the lost trainer won't report anything, its subscribers end
+		previous := r.trainerStatus
+		r.trainerStatus = d.status
+		forwardStatus(d.status, r.status)
+		previous.close()
+	}
+
+	// control was requested when connecting, the last command was lost
+	r.commands.setOffline(false)
+	r.commands.resend()
+}
@@ -0,0 +1,174 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRelay(t *testing.T) {
+	old, replacement := &fakeSource{}, &fakeSource{}
+	r := newRelay(old, func() {})
+	r.commands = &commands{}
+
+	c := make(chan int, 10)
+	r.AddListener(c)
+
+	old.WriteValue(100)
+	if v := <-c; v != 100 {
+		t.Errorf("expected 100, got %d", v)
+	}
+
+	_, _ = r.Write(250)
+	r.bind(replacement)
+	r.commands.resend()
+
+	if len(replacement.written) != 1 || replacement.written[0] != 250 {
+		t.Errorf("expected the target to be sent again, got %v", replacement.written)
+	}
+
+	replacement.WriteValue(200)
+	if v := <-c; v != 200 {
+		t.Errorf("expected the readings of the new source, got %d", v)
+	}
+}
+
+func TestRelayOffline(t *testing.T) {
+	old, replacement := &fakeSource{}, &fakeSource{}
+	r := newRelay(old, func() {})
+	r.commands = &commands{}
+
+	r.commands.setOffline(true)
+	if v, err := r.Write(250); err != nil || v != 250 {
+		t.Errorf("expected the target to be kept, got %d (%v)", v, err)
+	}
+	if len(old.written) != 0 {
+		t.Errorf("expected nothing to be written to the lost source, got %v", old.written)
+	}
+
+	r.bind(replacement)
+	r.commands.setOffline(false)
+	r.commands.resend()
+	if len(replacement.written) != 1 || replacement.written[0] != 250 {
+		t.Errorf("expected the kept target to be sent after the reconnect, got %v", replacement.written)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	oldPower, newPower := &fakeSource{}, &fakeSource{}
+
+	attempts := 0
+	connect := func() (Device, error) {
+		attempts++
+		if attempts == 1 {
+			return Device{}, errors.New("trainer is out of reach")
+		}
+		return NewDevice(WithPower(newPower), WithControl(mockController{})), nil
+	}
+
+	d, r := watch(NewDevice(WithPower(oldPower), WithControl(mockController{})), connect)
+	r.delay = time.Millisecond
+
+	events, _ := d.SubscribeConnection(context.Background(), 10)
+
+	readings := make(chan int, 10)
+	d.Power.AddListener(readings)
+	_, _ = d.Power.Write(180)
+
+	r.reconnect()
+
+	expected := []ConnectionEvent{
+		{Role: RoleTrainer, State: StateReconnecting},
+		{Role: RoleTrainer, State: StateReconnecting, Attempt: 1},
+		{Role: RoleTrainer, State: StateConnected},
+	}
+	for _, e := range expected {
+		actual := <-events.C
+		if actual.State != e.State || actual.Attempt != e.Attempt {
+			t.Errorf("expected %+v, got %+v", e, actual)
+		}
+	}
+
+	if len(newPower.written) != 1 || newPower.written[0] != 180 {
+		t.Errorf("expected the target to be restored, got %v", newPower.written)
+	}
+
+	newPower.WriteValue(190)
+	if v := <-readings; v != 190 {
+		t.Errorf("expected the listeners to get the readings of the reconnected trainer, got %d", v)
+	}
+}
+
+func TestReconnectReplaysLastCommand(t *testing.T) {
+	oldPower, newPower := &fakeSource{}, &fakeSource{}
+	newControl := &recordingControl{}
+	connect := func() (Device, error) {
+		return NewDevice(WithPower(newPower), WithControl(newControl)), nil
+	}
+
+	d, r := watch(NewDevice(WithPower(oldPower), WithControl(&recordingControl{})), connect)
+
+	// the workout left ERG mode after the last target power
+	_, _ = d.Power.Write(180)
+	climb := SimulationParams{Grade: 5, Crr: 0.004, CdA: 0.51}
+	if err := d.Control.SetSimulation(climb); err != nil {
+		t.Fatal(err)
+	}
+
+	r.reconnect()
+
+	if len(newPower.written) != 0 {
+		t.Errorf("expected the trainer not to be put back in ERG mode, got %v", newPower.written)
+	}
+	if len(newControl.simulations) != 1 || newControl.simulations[0] != climb {
+		t.Errorf("expected the simulation to be restored, got %v", newControl.simulations)
+	}
+}
+
+func TestReconnectEndsLostStatus(t *testing.T) {
+	oldStatus, newStatus := &statusCharacteristic{}, &statusCharacteristic{}
+	connect := func() (Device, error) {
+		return NewDevice(WithPower(&fakeSource{}), withStatus(newStatus)), nil
+	}
+
+	d, r := watch(NewDevice(WithPower(&fakeSource{}), withStatus(oldStatus)), connect)
+	// e.g. the goroutine that requests control again
+	lost, _ := oldStatus.Subscribe(context.Background(), 1)
+	events, _ := d.SubscribeStatus(context.Background(), 1)
+
+	r.reconnect()
+
+	if _, open := <-lost.C; open {
+		t.Error("expected the subscriptions to the lost trainer to end")
+	}
+
+	newStatus.publish(StatusEvent{Status: StatusStartedOrResumed})
+	if e := <-events.C; e.Status != StatusStartedOrResumed {
+		t.Errorf("expected the status of the reconnected trainer, got %+v", e)
+	}
+}
+
+func TestWatchDetectsSilence(t *testing.T) {
+	connected := make(chan struct{}, 1)
+	connect := func() (Device, error) {
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+		return NewDevice(WithPower(&fakeSource{})), nil
+	}
+
+	_, r := watch(NewDevice(WithPower(&fakeSource{})), connect)
+	r.timeout = 20 * time.Millisecond
+
+	done := make(chan struct{})
+	defer close(done)
+	go r.run(done)
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Error("expected a reconnect when the trainer stays silent")
+	}
+}
@@ -0,0 +1,171 @@
+package bluetooth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+const (
+	recordNotify = "notify"
+	recordWrite  = "write"
+	recordRead   = "read"
+)
+
+// Record is a raw notification or write of a characteristic
+type Record struct {
+	// Time is the time since the recording started
+	Time    time.Duration `json:"t"`
+	Kind    string        `json:"kind"`
+	Device  string        `json:"device"`
+	Service string        `json:"service"`
+	Char    string        `json:"char"`
+	Data    []byte        `json:"data"`
+}
+
+// Recorder writes every notification and write of the connected
+// devices as a line of JSON, so a ride can be replayed later.
+type Recorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+func NewRecorder(out io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(out), start: time.Now()}
+}
+
+// Err returns the first error that happened while recording
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// stop at the first error, the rest of the recording would be useless
+	if r.err != nil {
+		return
+	}
+
+	rec.Time = time.Since(r.start)
+	if err := r.enc.Encode(rec); err != nil {
+		r.err = err
+		slog.Error("could not record bluetooth traffic: " + err.Error())
+	}
+}
+
+// readRecords reads a recording
+func readRecords(in io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+// recordingAdapter records the traffic of the devices it connects to
+type recordingAdapter struct {
+	bluetoothadapter
+	recorder *Recorder
+}
+
+func (a recordingAdapter) Connect(address bluetooth.Address, params bluetooth.ConnectionParams) (bluetootdevice, error) {
+	device, err := a.bluetoothadapter.Connect(address, params)
+	if err != nil {
+		return nil, err
+	}
+	return recordingDevice{device, address.String(), a.recorder}, nil
+}
+
+type recordingDevice struct {
+	bluetootdevice
+	address  string
+	recorder *Recorder
+}
+
+func (d recordingDevice) DiscoverServices(uuids []bluetooth.UUID) ([]bluetoothservice, error) {
+	services, err := d.bluetootdevice.DiscoverServices(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range services {
+		services[i] = recordingService{s, d}
+	}
+	return services, nil
+}
+
+type recordingService struct {
+	bluetoothservice
+	device recordingDevice
+}
+
+func (s recordingService) DiscoverCharacteristics(uuids []bluetooth.UUID) ([]bluetoothcharacteristic, error) {
+	chars, err := s.bluetoothservice.DiscoverCharacteristics(uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, c := range chars {
+		chars[i] = recordingCharacteristic{c, s}
+	}
+	return chars, nil
+}
+
+type recordingCharacteristic struct {
+	bluetoothcharacteristic
+	service recordingService
+}
+
+func (c recordingCharacteristic) EnableNotifications(callback func(buf []byte)) error {
+	if callback == nil {
+		return c.bluetoothcharacteristic.EnableNotifications(nil)
+	}
+
+	return c.bluetoothcharacteristic.EnableNotifications(func(buf []byte) {
+		c.record(recordNotify, buf)
+		callback(buf)
+	})
+}
+
+func (c recordingCharacteristic) Write(p []byte) (int, error) {
+	c.record(recordWrite, p)
+	return c.bluetoothcharacteristic.Write(p)
+}
+
+func (c recordingCharacteristic) Read(data []byte) (int, error) {
+	n, err := c.bluetoothcharacteristic.Read(data)
+	if err == nil {
+		c.record(recordRead, data[:n])
+	}
+	return n, err
+}
+
+func (c recordingCharacteristic) record(kind string, data []byte) {
+	c.service.device.recorder.record(Record{
+		Kind:    kind,
+		Device:  c.service.device.address,
+		Service: c.service.UUID().String(),
+		Char:    c.UUID().String(),
+		Data:    data,
+	})
+}
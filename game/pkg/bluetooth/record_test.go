@@ -0,0 +1,100 @@
+package bluetooth
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var recording bytes.Buffer
+	recorder := NewRecorder(&recording)
+
+	trainer := newEmulatedTrainer("KICKR", "E1:EC:00:00:00:01")
+	adapter := newEmulatedAdapter(trainer.emulatedDevice)
+	d, err := connect(adapter, NewConnectOpts(WithScanTimeout(10*time.Millisecond), WithRecorder(recorder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Listen()
+
+	if _, err := d.Power.Write(250); err != nil {
+		t.Fatal(err)
+	}
+	// the readings come in at a pace the listeners can keep up with
+	for range 10 {
+		trainer.step(time.Second)
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if recorder.Err() != nil {
+		t.Fatal(recorder.Err())
+	}
+
+	records, err := readRecords(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notifications, writes int
+	for _, rec := range records {
+		switch rec.Kind {
+		case recordNotify:
+			notifications++
+		case recordWrite:
+			writes++
+		}
+	}
+	// the power and the bike data of every step
+	if notifications < 20 {
+		t.Errorf("expected the notifications to be recorded, got %d", notifications)
+	}
+	// requesting control and the target power
+	if writes != 2 {
+		t.Errorf("expected 2 writes to be recorded, got %d", writes)
+	}
+
+	replayed, err := Replay(bytes.NewReader(recording.Bytes()), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	power, _ := replayed.Power.Subscribe(context.Background(), 20)
+	replayed.Listen()
+
+	var values []int
+	for len(values) < 10 {
+		select {
+		case s := <-power.C:
+			values = append(values, s.Value)
+		case <-time.After(time.Second):
+			t.Fatalf("expected 10 replayed readings, got %v", values)
+		}
+	}
+
+	if values[0] >= 250 || values[9] != 250 {
+		t.Errorf("expected the power to ramp up to 250W like it was recorded, got %v", values)
+	}
+
+	if replayed.Info[RoleTrainer] != d.Info[RoleTrainer] {
+		t.Errorf("expected the recorded device information %+v, got %+v", d.Info[RoleTrainer], replayed.Info[RoleTrainer])
+	}
+
+	// the replay ends, that is no reason to reconnect
+	if _, ok := replayed.SubscribeConnection(context.Background(), 1); ok {
+		t.Error("expected the replayed trainer not to be watched")
+	}
+}
+
+func TestReplayInvalid(t *testing.T) {
+	if _, err := Replay(strings.NewReader("{\"t\": 1"), 1); err == nil {
+		t.Error("expected an error for a broken recording")
+	}
+	if _, err := Replay(strings.NewReader(""), 0); err == nil {
+		t.Error("expected an error for an invalid speed")
+	}
+	if _, err := Replay(strings.NewReader(""), 1); err == nil {
+		t.Error("expected an error for a recording without a trainer")
+	}
+}
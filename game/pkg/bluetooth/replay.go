@@ -0,0 +1,148 @@
+package bluetooth
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// maxReplayWait is how long the replay waits for a characteristic to be listened to
+const maxReplayWait = 5 * time.Second
+
+// Replay connects to the devices of a recording and plays back their
+// notifications through the same decoding as a ride with real devices.
+// speed is how many times faster than recorded the notifications come in.
+func Replay(in io.Reader, speed float64) (*Device, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("invalid replay speed %v", speed)
+	}
+
+	records, err := readRecords(in)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, chars, err := replayDevices(records)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := connect(newEmulatedAdapter(devices...), NewConnectOpts(WithScanTimeout(emulatorScanTimeout), withoutWatch()))
+	if err != nil {
+		return nil, err
+	}
+
+	go play(records, chars, speed)
+	return d, nil
+}
+
+// replayDevices creates a device for every device in the recording,
+// with the services and characteristics that were used.
+func replayDevices(records []Record) ([]*emulatedDevice, map[string]*emulatedCharacteristic, error) {
+	var devices []*emulatedDevice
+	byAddress := map[string]*emulatedDevice{}
+	chars := map[string]*emulatedCharacteristic{}
+
+	for _, rec := range records {
+		key := rec.Device + "/" + rec.Char
+		char, ok := chars[key]
+		if !ok {
+			serviceUUID, err := bluetooth.ParseUUID(rec.Service)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid service %q: %w", rec.Service, err)
+			}
+			charUUID, err := bluetooth.ParseUUID(rec.Char)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid characteristic %q: %w", rec.Char, err)
+			}
+
+			d, ok := byAddress[rec.Device]
+			if !ok {
+				d = newEmulatedDevice("", rec.Device)
+				byAddress[rec.Device] = d
+				devices = append(devices, d)
+			}
+
+			char = &emulatedCharacteristic{uuid: charUUID}
+			chars[key] = char
+			d.service(serviceUUID).chars = append(d.service(serviceUUID).chars, char)
+		}
+
+		// the device information is read when connecting, the last value is read again
+		if rec.Kind == recordRead {
+			char.setValue(rec.Data)
+		}
+
+		// whatever was written is accepted, e.g. the requests to the control point
+		if rec.Kind == recordWrite && char.onWrite == nil {
+			char.onWrite = func(buf []byte) {
+				if len(buf) > 0 {
+					char.notify([]byte{opResponseCode, buf[0], byte(ResultSuccess)})
+				}
+			}
+		}
+	}
+
+	return devices, chars, nil
+}
+
+// service returns the service with the uuid, it is added when it's not there yet
+func (d *emulatedDevice) service(uuid bluetooth.UUID) *emulatedService {
+	for _, s := range d.services {
+		if s.uuid == uuid {
+			return s
+		}
+	}
+
+	s := &emulatedService{uuid: uuid}
+	d.services = append(d.services, s)
+	return s
+}
+
+// play notifies the recorded notifications at the pace they were recorded,
+// times speed. The recorded writes are skipped, the replayed ride makes its own.
+//
+// Nothing could be notified before it was listened to, so the replay
+// stands still until the characteristic of the next notification is.
+func play(records []Record, chars map[string]*emulatedCharacteristic, speed float64) {
+	ignored := map[*emulatedCharacteristic]bool{}
+	start := time.Now()
+	first := time.Duration(-1)
+
+	// since returns how long after the start the record is replayed
+	since := func(rec Record) time.Duration {
+		return time.Duration(float64(rec.Time-first) / speed)
+	}
+
+	for _, rec := range records {
+		char := chars[rec.Device+"/"+rec.Char]
+		if rec.Kind != recordNotify || ignored[char] {
+			continue
+		}
+
+		if first < 0 {
+			first = rec.Time
+		}
+		time.Sleep(time.Until(start.Add(since(rec))))
+
+		select {
+		case <-char.subscribed():
+		default:
+			select {
+			case <-char.subscribed():
+				start = time.Now().Add(-since(rec))
+			case <-time.After(maxReplayWait):
+				slog.Info("Nobody listens to " + rec.Char + ", skipping its notifications")
+				ignored[char] = true
+				continue
+			}
+		}
+
+		char.notify(rec.Data)
+	}
+
+	slog.Info("Replay done")
+}
@@ -0,0 +1,58 @@
+package bluetooth
+
+import "time"
+
+// Unit is the unit of the value of a sample
+type Unit string
+
+const (
+	UnitWatt          Unit = "W"
+	UnitMetersPerHour Unit = "m/h"
+	UnitRpm           Unit = "rpm"
+	UnitBpm           Unit = "bpm"
+	UnitSeconds       Unit = "s"
+	UnitMilliseconds  Unit = "ms"
+	UnitPercent       Unit = "%"
+	// UnitLevel is used for the resistance level, it has no unit
+	UnitLevel Unit = ""
+)
+
+// Sample is a single reading of a sensor
+type Sample struct {
+	Value int
+	Unit  Unit
+	// Source is the role of the device that measured the value, e.g. "power meter"
+	Source string
+	// Time is when the reading came in. It holds a monotonic clock reading,
+	// so the time between samples is not affected by changes of the wall clock.
+	Time time.Time
+}
+
+// labeler is implemented by characteristics that stamp their samples
+type labeler interface {
+	label(unit Unit, source string)
+}
+
+// label stamps the samples of every characteristic
+// of the device with its unit and source.
+func (d *Device) label(source string) {
+	fields := []struct {
+		rw   readwriter
+		unit Unit
+	}{
+		{d.Power, UnitWatt},
+		{d.Speed, UnitMetersPerHour},
+		{d.Cadence, UnitRpm},
+		{d.Resistance, UnitLevel},
+		{d.Hr, UnitBpm},
+		{d.ElapsedTime, UnitSeconds},
+		{d.RR, UnitMilliseconds},
+		{d.Battery, UnitPercent},
+	}
+
+	for _, f := range fields {
+		if l, ok := f.rw.(labeler); ok {
+			l.label(f.unit, source)
+		}
+	}
+}
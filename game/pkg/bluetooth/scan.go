@@ -0,0 +1,101 @@
+package bluetooth
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Peripheral is a device found while scanning
+type Peripheral struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	RSSI    int16  `json:"rssi"`
+	// Services holds the supported services: FTMS, CP, CSC and HR
+	Services []string `json:"services"`
+}
+
+// Scan lists the nearby devices, sorted from the strongest signal
+func Scan(timeout time.Duration) ([]Peripheral, error) {
+	adapter := bluetooth.DefaultAdapter
+	if err := adapter.Enable(); err != nil {
+		return nil, err
+	}
+
+	return scanPeripherals(gattAdapter{adapter}, timeout), nil
+}
+
+func newPeripheral(device bluetooth.ScanResult) Peripheral {
+	return Peripheral{
+		Name:     device.LocalName(),
+		Address:  device.Address.String(),
+		RSSI:     device.RSSI,
+		Services: serviceNames(device),
+	}
+}
+
+func scanPeripherals(adapter bluetoothadapter, timeout time.Duration) []Peripheral {
+	var mu sync.Mutex
+	found := map[string]Peripheral{}
+
+	go func() {
+		err := adapter.Scan(func(_ *bluetooth.Adapter, device bluetooth.ScanResult) {
+			p := newPeripheral(device)
+
+			mu.Lock()
+			defer mu.Unlock()
+			// not every advertisement has the name
+			if p.Name == "" {
+				p.Name = found[p.Address].Name
+			}
+			found[p.Address] = p
+		})
+
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	<-time.After(timeout)
+	if err := adapter.StopScan(); err != nil {
+		slog.Info("Could not stop scanning")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	peripherals := make([]Peripheral, 0, len(found))
+	for _, p := range found {
+		peripherals = append(peripherals, p)
+	}
+
+	slices.SortFunc(peripherals, func(a, b Peripheral) int {
+		return cmp.Or(cmp.Compare(b.RSSI, a.RSSI), cmp.Compare(a.Address, b.Address))
+	})
+	return peripherals
+}
+
+// serviceNames returns the short names of the advertised services
+func serviceNames(adv advertisement) []string {
+	services := []struct {
+		name string
+		uuid bluetooth.UUID
+	}{
+		{"FTMS", ftmsServiceUuid},
+		{"CP", powServiceUuid},
+		{"CSC", speedCadenceServiceUuid},
+		{"HR", hrServiceUuid},
+	}
+
+	names := []string{}
+	for _, s := range services {
+		if adv.HasServiceUUID(s.uuid) {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
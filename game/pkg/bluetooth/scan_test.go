@@ -0,0 +1,67 @@
+package bluetooth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+	"tinygo.org/x/bluetooth"
+)
+
+func TestScanPeripherals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	adapter := NewMockbluetoothadapter(ctrl)
+
+	trainer := bluetooth.ScanResult{
+		RSSI:                 -50,
+		AdvertisementPayload: payload{name: "KICKR", services: services{ftmsServiceUuid, powServiceUuid}},
+	}
+	// the same device again without its name
+	unnamed := bluetooth.ScanResult{
+		RSSI:                 -40,
+		AdvertisementPayload: payload{services: services{ftmsServiceUuid, powServiceUuid}},
+	}
+
+	adapter.EXPECT().Scan(gomock.Any()).DoAndReturn(
+		func(callback func(*bluetooth.Adapter, bluetooth.ScanResult)) error {
+			callback(nil, trainer)
+			callback(nil, unnamed)
+			return nil
+		},
+	)
+	adapter.EXPECT().StopScan().Return(nil)
+
+	peripherals := scanPeripherals(adapter, 10*time.Millisecond)
+
+	expected := []Peripheral{{
+		Name:     "KICKR",
+		Address:  trainer.Address.String(),
+		RSSI:     -40,
+		Services: []string{"FTMS", "CP"},
+	}}
+	if !reflect.DeepEqual(peripherals, expected) {
+		t.Errorf("expected %+v, got %+v", expected, peripherals)
+	}
+}
+
+func TestScanRolesOnlyWanted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	adapter := NewMockbluetoothadapter(ctrl)
+
+	adapter.EXPECT().Scan(gomock.Any()).DoAndReturn(
+		func(callback func(*bluetooth.Adapter, bluetooth.ScanResult)) error {
+			callback(nil, bluetooth.ScanResult{AdvertisementPayload: payload{name: "KICKR", services: services{ftmsServiceUuid}}})
+			callback(nil, bluetooth.ScanResult{AdvertisementPayload: payload{name: "Polar H10", services: services{hrServiceUuid}}})
+			return nil
+		},
+	)
+	adapter.EXPECT().StopScan().Return(nil)
+
+	// the trainer is connected already, only the strap is missing
+	results := scanRoles(adapter, NewConnectOpts(WithScanTimeout(time.Second)), []Role{RoleHrMonitor})
+
+	if len(results) != 1 || results[RoleHrMonitor].LocalName() != "Polar H10" {
+		t.Errorf("expected only the heart rate strap, got %v", results)
+	}
+}
@@ -0,0 +1,420 @@
+package bluetooth
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Role is what a device is used for in a session
+type Role int
+
+const (
+	RoleTrainer Role = iota
+	RolePowerMeter
+	RoleHrMonitor
+	RoleSpeedCadence
+)
+
+var roles = []Role{RoleTrainer, RolePowerMeter, RoleHrMonitor, RoleSpeedCadence}
+
+// service is the service a device needs to fulfill the role
+func (r Role) service() bluetooth.UUID {
+	switch r {
+	case RoleTrainer:
+		return ftmsServiceUuid
+	case RolePowerMeter:
+		return powServiceUuid
+	case RoleHrMonitor:
+		return hrServiceUuid
+	default:
+		return speedCadenceServiceUuid
+	}
+}
+
+// ParseRole returns the role with the given key
+func ParseRole(key string) (Role, bool) {
+	for _, r := range roles {
+		if r.Key() == key {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// Key identifies the role when it is stored, unlike
+// String it does not change with the wording of the ui
+func (r Role) Key() string {
+	switch r {
+	case RoleTrainer:
+		return "trainer"
+	case RolePowerMeter:
+		return "power_meter"
+	case RoleHrMonitor:
+		return "hr_monitor"
+	case RoleSpeedCadence:
+		return "speed_cadence"
+	default:
+		return fmt.Sprintf("role_%d", int(r))
+	}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleTrainer:
+		return "trainer"
+	case RolePowerMeter:
+		return "power meter"
+	case RoleHrMonitor:
+		return "heart rate monitor"
+	case RoleSpeedCadence:
+		return "speed and cadence sensor"
+	default:
+		return fmt.Sprintf("role %d", int(r))
+	}
+}
+
+// Metric is a reading that multiple devices can provide
+type Metric int
+
+const (
+	MetricPower Metric = iota
+	MetricSpeed
+	MetricCadence
+	MetricHr
+)
+
+// of returns the readwriter of the metric on a device
+func (m Metric) of(d Device) readwriter {
+	switch m {
+	case MetricPower:
+		return d.Power
+	case MetricSpeed:
+		return d.Speed
+	case MetricCadence:
+		return d.Cadence
+	case MetricHr:
+		return d.Hr
+	default:
+		return nil
+	}
+}
+
+type ConnectOpts struct {
+	// ScanTimeout is how long to look for devices
+	ScanTimeout time.Duration
+
+	// ScanGrace is how long the scan goes on for the optional sensors
+	// once the trainer and the chosen or known devices are found.
+	ScanGrace time.Duration
+
+	// StaleAfter is how long the readings of a device are trusted,
+	// after that the next device in the priorities takes over.
+	StaleAfter time.Duration
+
+	// Priorities holds for every metric which roles provide it,
+	// the first connected role with fresh readings wins.
+	Priorities map[Metric][]Role
+
+	// PowerMatch corrects the target power of the trainer
+	// so that the power meter reads the target.
+	PowerMatch bool
+
+	// Devices holds the chosen device for a role by its address or
+	// a part of its name. Other devices are ignored for that role.
+	Devices map[Role]string
+
+	// Known holds the devices used before, they are
+	// connected directly without scanning for them.
+	Known map[Role]Peripheral
+
+	// Recorder records the traffic of all devices when it is set
+	Recorder *Recorder
+
+	// unwatched leaves the trainer without the reconnect watchdog,
+	// a replayed recording does not drop and can be silent for long.
+	unwatched bool
+}
+
+func WithScanTimeout(timeout time.Duration) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.ScanTimeout = timeout
+	}
+}
+
+func WithScanGrace(grace time.Duration) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.ScanGrace = grace
+	}
+}
+
+func WithStaleAfter(d time.Duration) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.StaleAfter = d
+	}
+}
+
+func WithPowerMatch(match bool) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.PowerMatch = match
+	}
+}
+
+func WithRecorder(r *Recorder) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.Recorder = r
+	}
+}
+
+// withoutWatch connects the trainer without reconnecting it
+func withoutWatch() func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.unwatched = true
+	}
+}
+
+// WithDevice binds a role to the device with the given
+// address or a name containing selector
+func WithDevice(role Role, selector string) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		if selector != "" {
+			opts.Devices[role] = selector
+		}
+	}
+}
+
+// WithKnownDevice remembers the device used for a role before
+func WithKnownDevice(role Role, p Peripheral) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.Known[role] = p
+	}
+}
+
+// WithPriority sets which roles provide a metric, the first one wins
+func WithPriority(m Metric, roles ...Role) func(*ConnectOpts) {
+	return func(opts *ConnectOpts) {
+		opts.Priorities[m] = roles
+	}
+}
+
+// NewConnectOpts prefers the dedicated sensors over the trainer,
+// they are usually more accurate.
+func NewConnectOpts(optsArgs ...func(*ConnectOpts)) ConnectOpts {
+	opts := ConnectOpts{
+		ScanTimeout: 10 * time.Second,
+		ScanGrace:   2 * time.Second,
+		StaleAfter:  3 * time.Second,
+		Priorities: map[Metric][]Role{
+			MetricPower:   {RolePowerMeter, RoleTrainer},
+			MetricSpeed:   {RoleSpeedCadence, RoleTrainer, RolePowerMeter},
+			MetricCadence: {RoleSpeedCadence, RolePowerMeter, RoleTrainer},
+			MetricHr:      {RoleHrMonitor, RoleTrainer},
+		},
+		Devices: map[Role]string{},
+		Known:   map[Role]Peripheral{},
+	}
+
+	for _, arg := range optsArgs {
+		arg(&opts)
+	}
+
+	return opts
+}
+
+// advertisement is the part of a scan result used to classify a device
+type advertisement interface {
+	HasServiceUUID(bluetooth.UUID) bool
+}
+
+// classify determines the role of a device by its advertised services.
+// Trainers also have cycling power, so FTMS is checked first.
+func classify(adv advertisement) (Role, bool) {
+	switch {
+	case adv.HasServiceUUID(ftmsServiceUuid):
+		return RoleTrainer, true
+	case adv.HasServiceUUID(powServiceUuid):
+		return RolePowerMeter, true
+	case adv.HasServiceUUID(hrServiceUuid):
+		return RoleHrMonitor, true
+	case adv.HasServiceUUID(speedCadenceServiceUuid):
+		return RoleSpeedCadence, true
+	default:
+		return 0, false
+	}
+}
+
+// roleOf returns the role of a scanned device. A device chosen for a
+// role always gets it, when the role has a chosen device others are ignored.
+func (opts ConnectOpts) roleOf(name string, address string, adv advertisement) (Role, bool) {
+	for role, selector := range opts.Devices {
+		if selected(selector, name, address) && adv.HasServiceUUID(role.service()) {
+			return role, true
+		}
+	}
+
+	role, ok := classify(adv)
+	if _, chosen := opts.Devices[role]; !ok || chosen {
+		return 0, false
+	}
+	return role, true
+}
+
+// selected reports whether the selector is the address
+// of the device or is part of its name
+func selected(selector string, name string, address string) bool {
+	if strings.EqualFold(selector, address) {
+		return true
+	}
+	return name != "" && strings.Contains(strings.ToLower(name), strings.ToLower(selector))
+}
+
+// required reports whether a scan has to wait for the role, that is
+// the trainer and the devices that were chosen or used before.
+func (opts ConnectOpts) required(role Role) bool {
+	_, chosen := opts.Devices[role]
+	_, known := opts.Known[role]
+	return role == RoleTrainer || chosen || known
+}
+
+// scanRoles returns the first device found for every wanted role.
+// It stops when all of them are found or when the timeout passed.
+// Once the required roles are found, the other ones get ScanGrace.
+func scanRoles(
+	adapter bluetoothadapter,
+	opts ConnectOpts,
+	want []Role,
+) map[Role]bluetooth.ScanResult {
+	var mu sync.Mutex
+	results := map[Role]bluetooth.ScanResult{}
+	done := make(chan struct{})
+
+	required := make(chan struct{})
+	var requiredOnce sync.Once
+	// checkRequired closes required when all required roles are found
+	checkRequired := func() {
+		for _, role := range want {
+			if _, found := results[role]; !found && opts.required(role) {
+				return
+			}
+		}
+		requiredOnce.Do(func() { close(required) })
+	}
+	checkRequired()
+
+	go func() {
+		slog.Info("Scanning bluetooth devices...")
+		err := adapter.Scan(func(_ *bluetooth.Adapter, device bluetooth.ScanResult) {
+			role, ok := opts.roleOf(device.LocalName(), device.Address.String(), device)
+			if !ok || !slices.Contains(want, role) {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if _, found := results[role]; found || len(results) == len(want) {
+				return
+			}
+
+			slog.Info(fmt.Sprintf("Found %s with uuid: %s", role, device.Address.String()))
+			results[role] = device
+			if len(results) == len(want) {
+				close(done)
+			}
+			checkRequired()
+		})
+
+		if err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+
+	timeout := time.After(opts.ScanTimeout)
+	select {
+	case <-done:
+	case <-timeout:
+	case <-required:
+		select {
+		case <-done:
+		case <-timeout:
+		case <-time.After(opts.ScanGrace):
+		}
+	}
+
+	if err := adapter.StopScan(); err != nil {
+		slog.Info("Could not stop scanning")
+	}
+
+	slog.Info("Scanning done...")
+	mu.Lock()
+	defer mu.Unlock()
+	return results
+}
+
+// combine merges the devices of a session into one device. The trainer
+// is the only one that can be controlled, the metrics come from the
+// device with the highest priority that has fresh readings.
+func combine(devices map[Role]Device, opts ConnectOpts) Device {
+	d := devices[RoleTrainer]
+	d.RR = devices[RoleHrMonitor].RR
+	d.offset = devices[RolePowerMeter].offset
+
+	d.Info = map[Role]DeviceInfo{}
+	var levels []readwriter
+	for _, role := range roles {
+		for r, info := range devices[role].Info {
+			d.Info[r] = info
+		}
+		if b := devices[role].Battery; b != nil {
+			levels = append(levels, b)
+		}
+	}
+	d.Battery = nil
+	if len(levels) > 0 {
+		d.Battery = newBatteries(levels...)
+	}
+
+	d.Power = opts.source(MetricPower, devices)
+	d.Speed = opts.source(MetricSpeed, devices)
+	d.Cadence = opts.source(MetricCadence, devices)
+	d.Hr = opts.source(MetricHr, devices)
+
+	meter, hasMeter := devices[RolePowerMeter]
+	trainer, hasTrainer := devices[RoleTrainer]
+	if opts.PowerMatch && hasMeter && hasTrainer && meter.Power != nil && trainer.Power != nil {
+		d.Power = newPowerMatch(d.Power, trainer.Power, meter.Power)
+	} else if opts.PowerMatch {
+		slog.Info("Power match needs a trainer and a power meter")
+	}
+
+	return d
+}
+
+// source returns the readwriter for a metric, it only
+// aggregates when multiple devices provide the metric.
+func (opts ConnectOpts) source(m Metric, devices map[Role]Device) readwriter {
+	var sources []readwriter
+	for _, role := range opts.Priorities[m] {
+		d, ok := devices[role]
+		if !ok {
+			continue
+		}
+
+		if rw := m.of(d); rw != nil {
+			sources = append(sources, rw)
+		}
+	}
+
+	switch len(sources) {
+	case 0:
+		return nil
+	case 1:
+		return sources[0]
+	default:
+		return newAggregate(opts.StaleAfter, sources...)
+	}
+}
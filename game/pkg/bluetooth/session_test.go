@@ -0,0 +1,216 @@
+package bluetooth
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+	"tinygo.org/x/bluetooth"
+)
+
+// services is an advertisement with the given services
+type services []bluetooth.UUID
+
+func (s services) HasServiceUUID(uuid bluetooth.UUID) bool {
+	for _, u := range s {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// payload is the advertisement of a named device
+type payload struct {
+	name string
+	services
+}
+
+func (p payload) LocalName() string                                     { return p.name }
+func (p payload) Bytes() []byte                                         { return nil }
+func (p payload) ManufacturerData() []bluetooth.ManufacturerDataElement { return nil }
+func (p payload) ServiceData() []bluetooth.ServiceDataElement           { return nil }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		adv      services
+		expected Role
+		ok       bool
+	}{
+		{name: "trainer", adv: services{powServiceUuid, ftmsServiceUuid}, expected: RoleTrainer, ok: true},
+		{name: "power meter", adv: services{powServiceUuid}, expected: RolePowerMeter, ok: true},
+		{name: "heart rate strap", adv: services{hrServiceUuid}, expected: RoleHrMonitor, ok: true},
+		{name: "cadence pod", adv: services{speedCadenceServiceUuid}, expected: RoleSpeedCadence, ok: true},
+		{name: "unknown", adv: services{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, ok := classify(tt.adv)
+			if ok != tt.ok || role != tt.expected {
+				t.Errorf("expected %s (%v), got %s (%v)", tt.expected, tt.ok, role, ok)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	trainerPower, meterPower, strapHr := &fakeSource{}, &fakeSource{readOnly: true}, &fakeSource{}
+	devices := map[Role]Device{
+		RoleTrainer:    NewDevice(WithPower(trainerPower), WithControl(mockController{})),
+		RolePowerMeter: NewDevice(WithPower(meterPower)),
+		RoleHrMonitor:  NewDevice(WithHr(strapHr)),
+	}
+
+	d := combine(devices, NewConnectOpts())
+
+	if _, ok := d.Power.(*aggregate); !ok {
+		t.Errorf("expected the power of both devices to be aggregated, got %T", d.Power)
+	}
+	if d.Hr != strapHr {
+		t.Error("expected the heart rate of the strap")
+	}
+	if d.Cadence != nil {
+		t.Error("expected no cadence")
+	}
+	if d.Control == nil {
+		t.Error("expected the trainer to be controllable")
+	}
+}
+
+func TestCombinePriority(t *testing.T) {
+	trainerPower, meterPower := &fakeSource{}, &fakeSource{}
+	devices := map[Role]Device{
+		RoleTrainer:    NewDevice(WithPower(trainerPower)),
+		RolePowerMeter: NewDevice(WithPower(meterPower)),
+	}
+
+	d := combine(devices, NewConnectOpts(WithPriority(MetricPower, RoleTrainer)))
+	if d.Power != trainerPower {
+		t.Error("expected only the trainer power")
+	}
+}
+
+func TestCombinePowerMatch(t *testing.T) {
+	devices := map[Role]Device{
+		RoleTrainer:    NewDevice(WithPower(&fakeSource{})),
+		RolePowerMeter: NewDevice(WithPower(&fakeSource{readOnly: true})),
+	}
+
+	d := combine(devices, NewConnectOpts(WithPowerMatch(true)))
+	if _, ok := d.Power.(*powerMatch); !ok {
+		t.Errorf("expected power match, got %T", d.Power)
+	}
+
+	delete(devices, RolePowerMeter)
+	d = combine(devices, NewConnectOpts(WithPowerMatch(true)))
+	if _, ok := d.Power.(*powerMatch); ok {
+		t.Error("expected no power match without a power meter")
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	opts := NewConnectOpts(WithDevice(RoleTrainer, "kickr"), WithDevice(RoleHrMonitor, "AA:BB:CC:DD:EE:FF"))
+
+	tests := []struct {
+		name     string
+		device   string
+		address  string
+		adv      services
+		expected Role
+		ok       bool
+	}{
+		{name: "chosen trainer", device: "KICKR CORE 1234", adv: services{ftmsServiceUuid}, expected: RoleTrainer, ok: true},
+		{name: "other trainer", device: "Tacx Neo", adv: services{ftmsServiceUuid}},
+		{name: "chosen strap by address", device: "Polar H10", address: "aa:bb:cc:dd:ee:ff", adv: services{hrServiceUuid}, expected: RoleHrMonitor, ok: true},
+		{name: "chosen strap by name is not selected", device: "Polar H10", address: "11:22:33:44:55:66", adv: services{hrServiceUuid}},
+		{name: "chosen trainer as hr source", device: "KICKR CORE", adv: services{hrServiceUuid}},
+		{name: "power meter without choice", device: "Assioma", adv: services{powServiceUuid}, expected: RolePowerMeter, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, ok := opts.roleOf(tt.device, tt.address, tt.adv)
+			if ok != tt.ok || role != tt.expected {
+				t.Errorf("expected %s (%v), got %s (%v)", tt.expected, tt.ok, role, ok)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	for _, r := range roles {
+		parsed, ok := ParseRole(r.Key())
+		if !ok || parsed != r {
+			t.Errorf("expected %s, got %s (%v)", r, parsed, ok)
+		}
+	}
+
+	if _, ok := ParseRole("toaster"); ok {
+		t.Error("expected no role for an unknown key")
+	}
+}
+
+// scanUntilStopped advertises the devices one after the other
+// with the given pause, like a scan it lasts until it is stopped
+func scanUntilStopped(adapter *Mockbluetoothadapter, pause time.Duration, devices ...bluetooth.ScanResult) {
+	stopped := make(chan struct{})
+	adapter.EXPECT().Scan(gomock.Any()).DoAndReturn(
+		func(callback func(*bluetooth.Adapter, bluetooth.ScanResult)) error {
+			for _, device := range devices {
+				callback(nil, device)
+				time.Sleep(pause)
+			}
+			<-stopped
+			return nil
+		},
+	)
+	adapter.EXPECT().StopScan().DoAndReturn(func() error {
+		close(stopped)
+		return nil
+	})
+}
+
+func TestScanRolesStopsAfterTrainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	adapter := NewMockbluetoothadapter(ctrl)
+
+	scanUntilStopped(adapter, 0,
+		bluetooth.ScanResult{AdvertisementPayload: payload{name: "KICKR", services: services{ftmsServiceUuid}}},
+	)
+
+	// the optional sensors are not around, only the grace is waited for them
+	opts := NewConnectOpts(WithScanTimeout(10*time.Second), WithScanGrace(10*time.Millisecond))
+	start := time.Now()
+	results := scanRoles(adapter, opts, roles)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scan to stop shortly after the trainer, took %s", elapsed)
+	}
+	if len(results) != 1 || results[RoleTrainer].LocalName() != "KICKR" {
+		t.Errorf("expected only the trainer, got %v", results)
+	}
+}
+
+func TestScanRolesWaitsForChosen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	adapter := NewMockbluetoothadapter(ctrl)
+
+	scanUntilStopped(adapter, 50*time.Millisecond,
+		bluetooth.ScanResult{AdvertisementPayload: payload{name: "KICKR", services: services{ftmsServiceUuid}}},
+		bluetooth.ScanResult{AdvertisementPayload: payload{name: "Polar H10", services: services{hrServiceUuid}}},
+	)
+
+	// the strap was chosen, the grace does not apply to it
+	opts := NewConnectOpts(
+		WithScanTimeout(10*time.Second),
+		WithScanGrace(10*time.Millisecond),
+		WithDevice(RoleHrMonitor, "Polar"),
+	)
+	results := scanRoles(adapter, opts, roles)
+
+	if len(results) != 2 || results[RoleHrMonitor].LocalName() != "Polar H10" {
+		t.Errorf("expected the trainer and the chosen strap, got %v", results)
+	}
+}
@@ -0,0 +1,90 @@
+package bluetooth
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// MachineStatus is an op code of the fitness machine status
+// characteristic, see section 4.17 of the FTMS spec
+type MachineStatus byte
+
+const (
+	StatusReset                    MachineStatus = 0x01
+	StatusStoppedOrPaused          MachineStatus = 0x02
+	StatusStoppedBySafetyKey       MachineStatus = 0x03
+	StatusStartedOrResumed         MachineStatus = 0x04
+	StatusTargetResistanceChanged  MachineStatus = 0x07
+	StatusTargetPowerChanged       MachineStatus = 0x08
+	StatusSimulationChanged        MachineStatus = 0x12
+	StatusWheelCircumferenceChange MachineStatus = 0x13
+	StatusSpinDown                 MachineStatus = 0x14
+	StatusTargetCadenceChanged     MachineStatus = 0x15
+	StatusControlPermissionLost    MachineStatus = 0xff
+)
+
+func (s MachineStatus) String() string {
+	switch s {
+	case StatusReset:
+		return "reset"
+	case StatusStoppedOrPaused:
+		return "stopped or paused by the user"
+	case StatusStoppedBySafetyKey:
+		return "stopped by safety key"
+	case StatusStartedOrResumed:
+		return "started or resumed by the user"
+	case StatusTargetResistanceChanged:
+		return "target resistance changed"
+	case StatusTargetPowerChanged:
+		return "target power changed"
+	case StatusSimulationChanged:
+		return "indoor bike simulation parameters changed"
+	case StatusWheelCircumferenceChange:
+		return "wheel circumference changed"
+	case StatusSpinDown:
+		return "spin down status"
+	case StatusTargetCadenceChanged:
+		return "target cadence changed"
+	case StatusControlPermissionLost:
+		return "control permission lost"
+	default:
+		return fmt.Sprintf("status 0x%02x", byte(s))
+	}
+}
+
+// StatusEvent is a notification of the fitness machine status,
+// Params holds the parameters of the status as sent by the trainer.
+type StatusEvent struct {
+	Status MachineStatus
+	Params []byte
+}
+
+func decodeStatusEvent(buf []byte) (StatusEvent, error) {
+	if len(buf) == 0 {
+		return StatusEvent{}, errShortBuffer
+	}
+
+	return StatusEvent{Status: MachineStatus(buf[0]), Params: buf[1:]}, nil
+}
+
+// statusCharacteristic passes the fitness machine status to its subscribers
+type statusCharacteristic struct {
+	char bluetoothcharacteristic
+
+	hub[StatusEvent]
+}
+
+func (s *statusCharacteristic) enable() error {
+	return s.char.EnableNotifications(s.notify)
+}
+
+func (s *statusCharacteristic) notify(buf []byte) {
+	event, err := decodeStatusEvent(buf)
+	if err != nil {
+		slog.Debug(err.Error())
+		return
+	}
+
+	slog.Info("Trainer status: " + event.Status.String())
+	s.publish(event)
+}
@@ -0,0 +1,111 @@
+package bluetooth
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Target is what the trainer is asked to do, ride
+// Power in ERG mode or let the rider free ride.
+type Target struct {
+	Power    int
+	FreeRide bool
+}
+
+// Targets sends the targets of a workout to the trainer. A target is
+// only sent when it changed. When a free ride starts, the trainer is
+// taken out of ERG mode once by simulating a flat road, the next ERG
+// target puts it back in ERG mode.
+type Targets struct {
+	device *Device
+	last   Target
+	sent   bool
+}
+
+func NewTargets(d *Device) *Targets {
+	return &Targets{device: d}
+}
+
+// Set sends the target when it differs from the last one that was
+// sent. A target that failed is sent again on the next call.
+func (t *Targets) Set(target Target) error {
+	if t.sent && target == t.last {
+		return nil
+	}
+
+	if err := t.send(target); err != nil {
+		return err
+	}
+
+	t.last, t.sent = target, true
+	return nil
+}
+
+func (t *Targets) send(target Target) error {
+	if !target.FreeRide {
+		_, err := t.device.Power.Write(target.Power)
+		return err
+	}
+
+	// a power match would put the trainer back in ERG mode
+	if s, ok := t.device.Power.(suspender); ok {
+		s.suspend()
+	}
+
+	// the last target stays in ERG mode while free riding a trainer without control
+	if t.device.Control == nil {
+		slog.Info("Trainer can't leave ERG mode for the free ride")
+		return nil
+	}
+	return t.device.Control.SetSimulation(DefaultSimulation)
+}
+
+// suspender is a power source that writes to the
+// trainer on its own, it stops during a free ride.
+type suspender interface {
+	suspend()
+}
+
+// TargetWriter sets the targets from its own goroutine, so the caller
+// never waits for the trainer to respond. Only the latest target is
+// set, a target that is replaced before its turn is skipped.
+type TargetWriter struct {
+	targets *Targets
+	latest  chan Target
+}
+
+func NewTargetWriter(d *Device) *TargetWriter {
+	return &TargetWriter{targets: NewTargets(d), latest: make(chan Target, 1)}
+}
+
+// Set replaces the target that waits to be set, it does not block.
+// It is meant to be called from a single goroutine.
+func (w *TargetWriter) Set(target Target) {
+	for {
+		select {
+		case w.latest <- target:
+			return
+		default:
+		}
+
+		// drop the waiting target, the new one replaces it
+		select {
+		case <-w.latest:
+		default:
+		}
+	}
+}
+
+// Run sets the targets until ctx is done, the errors are logged
+func (w *TargetWriter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case target := <-w.latest:
+			if err := w.targets.Set(target); err != nil {
+				slog.Error("could not set the target: " + err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTargetsOnlyChanged(t *testing.T) {
+	power := &fakeSource{}
+	d := NewDevice(WithPower(power))
+	targets := NewTargets(&d)
+
+	for _, p := range []int{200, 200, 250, 250, 200} {
+		if err := targets.Set(Target{Power: p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(power.written, []int{200, 250, 200}) {
+		t.Errorf("expected only the changed targets to be written, got %v", power.written)
+	}
+}
+
+// mockController accepts the requests that can be encoded
+type mockController struct{}
+
+func (mockController) SetSimulation(p SimulationParams) error {
+	_, err := encodeSimulation(p)
+	return err
+}
+
+func (mockController) SetResistance(level float64) error {
+	_, err := encodeResistance(level)
+	return err
+}
+
+func (mockController) StartSpinDown() (SpinDownTarget, error) {
+	return SpinDownTarget{}, nil
+}
+
+// recordingControl records the requests to leave ERG mode
+type recordingControl struct {
+	mockController
+	simulations []SimulationParams
+}
+
+func (c *recordingControl) SetSimulation(p SimulationParams) error {
+	c.simulations = append(c.simulations, p)
+	return nil
+}
+
+func TestTargetsFreeRide(t *testing.T) {
+	power, control := &fakeSource{}, &recordingControl{}
+	d := NewDevice(WithPower(power), WithControl(control))
+	targets := NewTargets(&d)
+
+	ticks := []Target{
+		{Power: 200},
+		{Power: 200},
+		// the free ride starts, ERG mode is left once
+		{FreeRide: true},
+		{FreeRide: true},
+		// the next ERG segment starts with the same target as before the free ride
+		{Power: 200},
+		{Power: 250},
+	}
+	for _, target := range ticks {
+		if err := targets.Set(target); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reflect.DeepEqual(power.written, []int{200, 200, 250}) {
+		t.Errorf("expected the targets around the free ride to be written, got %v", power.written)
+	}
+	if !reflect.DeepEqual(control.simulations, []SimulationParams{DefaultSimulation}) {
+		t.Errorf("expected ERG mode to be left once with a flat road, got %v", control.simulations)
+	}
+}
+
+func TestTargetsFreeRidePowerMatch(t *testing.T) {
+	p, trainer := newTestPowerMatch()
+	d := NewDevice(WithPower(p), WithControl(&recordingControl{}))
+	targets := NewTargets(&d)
+
+	if err := targets.Set(Target{Power: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if err := targets.Set(Target{FreeRide: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rider rides harder than the last target, that is no reason to correct it
+	measureWindow(p, 250)
+	measureWindow(p, 260)
+	if !reflect.DeepEqual(trainer.written, []int{200}) {
+		t.Errorf("expected no writes to the trainer during the free ride, got %v", trainer.written)
+	}
+}
+
+func TestTargetsRetry(t *testing.T) {
+	power := &fakeSource{readOnly: true}
+	d := NewDevice(WithPower(power))
+	targets := NewTargets(&d)
+
+	if err := targets.Set(Target{Power: 200}); !errors.Is(err, errReadOnly) {
+		t.Fatalf("expected the write to fail, got %v", err)
+	}
+
+	power.readOnly = false
+	if err := targets.Set(Target{Power: 200}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(power.written, []int{200}) {
+		t.Errorf("expected the failed target to be sent again, got %v", power.written)
+	}
+}
+
+// slowSource is a readwriter that takes a while to accept a target
+type slowSource struct {
+	fakeSource
+	mu      sync.Mutex
+	release chan struct{}
+}
+
+func (s *slowSource) Write(v int) (int, error) {
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fakeSource.Write(v)
+}
+
+func (s *slowSource) writes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.written...)
+}
+
+func TestTargetWriter(t *testing.T) {
+	power := &slowSource{release: make(chan struct{})}
+	d := NewDevice(WithPower(power))
+	w := NewTargetWriter(&d)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// the first target waits for the trainer, meanwhile Set does not block
+	w.Set(Target{Power: 100})
+	time.Sleep(10 * time.Millisecond)
+	for _, p := range []int{150, 200, 250} {
+		w.Set(Target{Power: p})
+	}
+	close(power.release)
+
+	deadline := time.After(time.Second)
+	for !reflect.DeepEqual(power.writes(), []int{100, 250}) {
+		select {
+		case <-deadline:
+			t.Fatalf("expected only the first and the latest target to be written, got %v", power.writes())
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -7,16 +7,28 @@ import (
 var (
 	ftmsUUID                       = "00001826-0000-1000-8000-00805f9b34fb"
 	fitnessMachineControlPointUUID = "00002ad9-0000-1000-8000-00805f9b34fb"
+	fitnessMachineStatusUUID       = "00002ada-0000-1000-8000-00805f9b34fb"
 	cyclingPower                   = "00001818-0000-1000-8000-00805f9b34fb"
 
 	cyclingSpeedAndCadence = "00001816-0000-1000-8000-00805f9b34fb"
+	heartRate              = "0000180d-0000-1000-8000-00805f9b34fb"
+	deviceInformation      = "0000180a-0000-1000-8000-00805f9b34fb"
+	batteryService         = "0000180f-0000-1000-8000-00805f9b34fb"
 
 	// notice that the only thing that's different from the ftmsUUID is the first segment.
 	// this is the case with all uuids
 	// file:///Users/cedricvanhaverbeke/Downloads/GATT_Specification_Supplement_v5.pdf
 	// https://gist.github.com/sam016/4abe921b5a9ee27f67b3686910293026
-	// var indoorBikeData = "00002ad2-0000-1000-8000-00805f9b34fb"
-	cyclingPowerMeasureMent = "00002a63-0000-1000-8000-00805f9b34fb"
+	indoorBikeDataUUID       = "00002ad2-0000-1000-8000-00805f9b34fb"
+	cyclingPowerMeasureMent  = "00002a63-0000-1000-8000-00805f9b34fb"
+	heartRateMeasurementUUID = "00002a37-0000-1000-8000-00805f9b34fb"
+	cscMeasurementUUID       = "00002a5b-0000-1000-8000-00805f9b34fb"
+	cyclingPowerControlPoint = "00002a66-0000-1000-8000-00805f9b34fb"
+	manufacturerNameUUID     = "00002a29-0000-1000-8000-00805f9b34fb"
+	modelNumberUUID          = "00002a24-0000-1000-8000-00805f9b34fb"
+	serialNumberUUID         = "00002a25-0000-1000-8000-00805f9b34fb"
+	firmwareRevisionUUID     = "00002a26-0000-1000-8000-00805f9b34fb"
+	batteryLevelUUID         = "00002a19-0000-1000-8000-00805f9b34fb"
 
 	// this stuff should not be available in the whole bluetooth package
 	// instead we should have one struct with uuids or something
@@ -24,10 +36,23 @@ var (
 	ftmsServiceUuid         bluetooth.UUID
 	powServiceUuid          bluetooth.UUID
 	speedCadenceServiceUuid bluetooth.UUID
+	hrServiceUuid           bluetooth.UUID
+	deviceInfoServiceUuid   bluetooth.UUID
+	batteryServiceUuid      bluetooth.UUID
 
 	// these are the characteristics itself
 	FTMSCharUuid                   bluetooth.UUID
 	cyclingPowerCharacteristicUuid bluetooth.UUID
+	indoorBikeDataCharUuid         bluetooth.UUID
+	machineStatusCharUuid          bluetooth.UUID
+	hrMeasurementCharUuid          bluetooth.UUID
+	cscMeasurementCharUuid         bluetooth.UUID
+	cpControlPointCharUuid         bluetooth.UUID
+	manufacturerNameCharUuid       bluetooth.UUID
+	modelNumberCharUuid            bluetooth.UUID
+	serialNumberCharUuid           bluetooth.UUID
+	firmwareRevisionCharUuid       bluetooth.UUID
+	batteryLevelCharUuid           bluetooth.UUID
 )
 
 // initializes id's. This will normally always work.
@@ -57,4 +82,69 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	indoorBikeDataCharUuid, err = bluetooth.ParseUUID(indoorBikeDataUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	machineStatusCharUuid, err = bluetooth.ParseUUID(fitnessMachineStatusUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	hrServiceUuid, err = bluetooth.ParseUUID(heartRate)
+	if err != nil {
+		panic(err)
+	}
+
+	hrMeasurementCharUuid, err = bluetooth.ParseUUID(heartRateMeasurementUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	cscMeasurementCharUuid, err = bluetooth.ParseUUID(cscMeasurementUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	cpControlPointCharUuid, err = bluetooth.ParseUUID(cyclingPowerControlPoint)
+	if err != nil {
+		panic(err)
+	}
+
+	deviceInfoServiceUuid, err = bluetooth.ParseUUID(deviceInformation)
+	if err != nil {
+		panic(err)
+	}
+
+	batteryServiceUuid, err = bluetooth.ParseUUID(batteryService)
+	if err != nil {
+		panic(err)
+	}
+
+	manufacturerNameCharUuid, err = bluetooth.ParseUUID(manufacturerNameUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	modelNumberCharUuid, err = bluetooth.ParseUUID(modelNumberUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	serialNumberCharUuid, err = bluetooth.ParseUUID(serialNumberUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	firmwareRevisionCharUuid, err = bluetooth.ParseUUID(firmwareRevisionUUID)
+	if err != nil {
+		panic(err)
+	}
+
+	batteryLevelCharUuid, err = bluetooth.ParseUUID(batteryLevelUUID)
+	if err != nil {
+		panic(err)
+	}
 }
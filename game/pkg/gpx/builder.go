@@ -1,63 +1,39 @@
 package gpx
 
 import (
+	"context"
 	"encoding/xml"
 	"io"
-	"sync"
+	"sync/atomic"
 
 	"overlay/pkg/bluetooth"
 )
 
-type chanAvailability struct {
-	c         chan int
-	available bool
-}
-
-func setupChannels(
-	trainer *bluetooth.Device,
-) (power chanAvailability, cadence chanAvailability) {
-	filePowerChan := make(chan int)
-	hasPower := trainer.Power.AddListener(filePowerChan)
-	power = chanAvailability{c: filePowerChan, available: hasPower}
+// trackpointBuffer is the number of power readings that are kept
+// while a trackpoint is added, so none of them get lost
+const trackpointBuffer = 16
 
-	// fileCadenceChar := make(chan int)
-	// hasCadence := trainer.Cadence.AddListener(fileCadenceChar)
-	// cadence = chanAvailability{c: fileCadenceChar, available: hasCadence}
-
-	// the values are implicitely returned but I don't like that
-	// it's just for readability when using this function
-	return power, cadence
+type subscriber interface {
+	Subscribe(ctx context.Context, size int) (*bluetooth.Subscription[bluetooth.Sample], bool)
 }
 
-func valuesWait(power chanAvailability, cadence chanAvailability) (int, int) {
-	wg := sync.WaitGroup{}
-	var powV int
-	var cadV int
-
-	wg.Add(1)
-	go func() {
-		if !power.available {
-			wg.Done()
-		}
+// latest keeps value up to date with the readings of a
+// characteristic. Nothing happens when it is not available.
+func latest(ctx context.Context, char subscriber, value *atomic.Int64) {
+	if char == nil {
+		return
+	}
 
-		p := <-power.c
-		powV = p
-		wg.Done()
-	}()
+	sub, ok := char.Subscribe(ctx, 1)
+	if !ok {
+		return
+	}
 
-	wg.Add(1)
 	go func() {
-		if !cadence.available {
-			wg.Done()
+		for v := range sub.C {
+			value.Store(int64(v.Value))
 		}
-
-		c := <-cadence.c
-		cadV = c
-		wg.Done()
 	}()
-
-	wg.Wait()
-	return powV, cadV
 }
 
 func (data *Gpx) Write(out io.Writer) error {
@@ -78,18 +54,29 @@ func (data *Gpx) Write(out io.Writer) error {
 	return nil
 }
 
-// Build waits for a trackpoint
-// to be ready to be added to
-// the gpx struct
-func (data *Gpx) Build(trainer *bluetooth.Device) {
-	power, cadence := setupChannels(trainer)
+// Build adds a trackpoint for every power reading of the trainer at
+// the time it was read, stamped with the latest heart rate and cadence.
+// It returns when ctx is done.
+func (data *Gpx) Build(ctx context.Context, trainer *bluetooth.Device) {
+	var hr, cadence atomic.Int64
+	latest(ctx, trainer.Hr, &hr)
+	latest(ctx, trainer.Cadence, &cadence)
 
-	for {
-		powV, cadV := valuesWait(power, cadence)
+	if trainer.Power == nil {
+		return
+	}
+
+	power, ok := trainer.Power.Subscribe(ctx, trackpointBuffer)
+	if !ok {
+		return
+	}
 
+	for p := range power.C {
 		tp := NewTrackpoint(
-			WithPower(powV),
-			WithCadence(cadV),
+			WithTime(p.Time),
+			WithPower(p.Value),
+			WithCadence(int(cadence.Load())),
+			WithHr(int(hr.Load())),
 		)
 
 		data.AddTrackpoint(tp)
@@ -34,6 +34,13 @@ func NewTrackpoint(opts ...trkOpt) trkpt {
 	return pt
 }
 
+// WithTime sets the time of the trackpoint, by default it is now
+func WithTime(t time.Time) trkOpt {
+	return func(tp *trkpt) {
+		tp.Time = t.Format(time.RFC3339)
+	}
+}
+
 func WithPower(power int) trkOpt {
 	return func(tp *trkpt) {
 		tp.Extensions.Power = power
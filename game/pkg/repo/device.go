@@ -0,0 +1,138 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// MaxFailures is how many times in a row a device can be
+// unreachable before it is forgotten
+const MaxFailures = 3
+
+// DeviceRepo remembers the bluetooth devices that were used
+// for every role, so they can be connected without scanning.
+type DeviceRepo struct {
+	db *sql.DB
+}
+
+type DeviceRecord struct {
+	Role       string    `json:"role"`
+	Address    string    `json:"address"`
+	Name       string    `json:"name"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Failures counts how many times in a row the device was unreachable
+	Failures int `json:"failures"`
+}
+
+func NewDeviceRepo(dbPath string) (*DeviceRepo, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	repo := &DeviceRepo{db: db}
+	if err := repo.createTable(); err != nil {
+		return nil, fmt.Errorf("failed to create table: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *DeviceRepo) createTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS devices (
+		role TEXT PRIMARY KEY,
+		address TEXT NOT NULL,
+		name TEXT NOT NULL,
+		last_used_at DATETIME NOT NULL,
+		failures INTEGER NOT NULL DEFAULT 0
+	);`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Save remembers the device used for a role, it
+// replaces the device that was used before.
+// The device was reached, so its failures are reset.
+func (r *DeviceRepo) Save(role string, address string, name string) error {
+	query := `
+	INSERT INTO devices (role, address, name, last_used_at, failures)
+	VALUES (?, ?, ?, ?, 0)
+	ON CONFLICT (role) DO UPDATE SET
+		address = excluded.address,
+		name = excluded.name,
+		last_used_at = excluded.last_used_at,
+		failures = 0
+	`
+
+	if _, err := r.db.Exec(query, role, address, name, time.Now().Round(0)); err != nil {
+		return fmt.Errorf("failed to save device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DeviceRepo) GetAll() ([]*DeviceRecord, error) {
+	rows, err := r.db.Query(`SELECT role, address, name, last_used_at, failures FROM devices ORDER BY role`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query devices: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*DeviceRecord
+	for rows.Next() {
+		record := &DeviceRecord{}
+		if err := rows.Scan(&record.Role, &record.Address, &record.Name, &record.LastUsedAt, &record.Failures); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// MarkUnreachable counts that the device of a role could not be reached.
+// It is forgotten after MaxFailures times in a row, forgotten tells if it was.
+func (r *DeviceRepo) MarkUnreachable(role string) (forgotten bool, err error) {
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`UPDATE devices SET failures = failures + 1 WHERE role = ?`, role); err != nil {
+		return false, fmt.Errorf("failed to mark device unreachable: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM devices WHERE role = ? AND failures >= ?`, role, MaxFailures)
+	if err != nil {
+		return false, fmt.Errorf("failed to forget device: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to forget device: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// Delete forgets the device of a role
+func (r *DeviceRepo) Delete(role string) error {
+	if _, err := r.db.Exec(`DELETE FROM devices WHERE role = ?`, role); err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *DeviceRepo) Close() error {
+	return r.db.Close()
+}
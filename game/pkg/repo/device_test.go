@@ -0,0 +1,99 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"overlay/pkg/repo"
+)
+
+func TestDeviceRepo(t *testing.T) {
+	r, err := repo.NewDeviceRepo(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+
+	if err := r.Save("trainer", "AA:BB:CC:DD:EE:FF", "KICKR"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save("hr_monitor", "11:22:33:44:55:66", "Polar H10"); err != nil {
+		t.Fatal(err)
+	}
+	// a new trainer replaces the old one
+	if err := r.Save("trainer", "FF:EE:DD:CC:BB:AA", "Neo"); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(records))
+	}
+	if records[1].Role != "trainer" || records[1].Address != "FF:EE:DD:CC:BB:AA" || records[1].Name != "Neo" {
+		t.Errorf("expected the new trainer, got %+v", records[1])
+	}
+
+	if err := r.Delete("trainer"); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err = r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Role != "hr_monitor" {
+		t.Errorf("expected only the heart rate monitor, got %+v", records)
+	}
+}
+
+func TestDeviceRepoForgetsUnreachable(t *testing.T) {
+	r, err := repo.NewDeviceRepo(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+
+	if err := r.Save("hr_monitor", "11:22:33:44:55:66", "Polar H10"); err != nil {
+		t.Fatal(err)
+	}
+
+	unreachable := func(times int) bool {
+		t.Helper()
+		var forgotten bool
+		for range times {
+			if forgotten, err = r.MarkUnreachable("hr_monitor"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return forgotten
+	}
+
+	// reaching the device again resets its failures
+	if unreachable(repo.MaxFailures - 1) {
+		t.Fatal("expected the device to be remembered")
+	}
+	if err := r.Save("hr_monitor", "11:22:33:44:55:66", "Polar H10"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Failures != 0 {
+		t.Fatalf("expected the failures to be reset, got %+v", records)
+	}
+
+	if unreachable(repo.MaxFailures - 1) {
+		t.Fatal("expected the device to be remembered")
+	}
+	if !unreachable(1) {
+		t.Fatal("expected the device to be forgotten")
+	}
+	if records, err := r.GetAll(); err != nil || len(records) != 0 {
+		t.Errorf("expected no devices, got %+v (%v)", records, err)
+	}
+}
@@ -22,6 +22,21 @@ type GPXRecord struct {
 	Data      string    `json:"data"` // GPX data stored as JSON string
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Devices are the devices the ride was done with
+	Devices []RideDevice `json:"devices"`
+}
+
+// RideDevice is a device a ride was done with, as it was when the ride ended
+type RideDevice struct {
+	Role         string `json:"role"`
+	Name         string `json:"name"`
+	Address      string `json:"address"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Serial       string `json:"serial,omitempty"`
+	Firmware     string `json:"firmware,omitempty"`
+	// Battery is the battery level in %, it is -1 when it is unknown
+	Battery int `json:"battery"`
 }
 
 func NewGPXRepo(dbPath string) (*GPXRepo, error) {
@@ -49,23 +64,63 @@ func (r *GPXRepo) createTable() error {
 		updated_at DATETIME NOT NULL
 	);`
 
-	_, err := r.db.Exec(query)
-	return err
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+	return r.migrate()
 }
 
-func (r *GPXRepo) Create(name string, gpxData gpx.Gpx) (*GPXRecord, error) {
+// migrate adds the columns that were added after the table was created
+func (r *GPXRepo) migrate() error {
+	rows, err := r.db.Query(`SELECT name FROM pragma_table_info('gpx_files')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !columns["devices"] {
+		if _, err := r.db.Exec(`ALTER TABLE gpx_files ADD COLUMN devices TEXT NOT NULL DEFAULT '[]'`); err != nil {
+			return fmt.Errorf("failed to add devices column: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Create stores a ride with the devices it was done with
+func (r *GPXRepo) Create(name string, gpxData gpx.Gpx, devices ...RideDevice) (*GPXRecord, error) {
 	dataXML, err := xml.Marshal(gpxData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal GPX data: %w", err)
 	}
 
+	if devices == nil {
+		devices = []RideDevice{}
+	}
+	devicesJSON, err := json.Marshal(devices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal devices: %w", err)
+	}
+
 	now := time.Now().Round(0)
 	query := `
-	INSERT INTO gpx_files (name, data, created_at, updated_at)
-	VALUES (?, ?, ?, ?)
+	INSERT INTO gpx_files (name, data, created_at, updated_at, devices)
+	VALUES (?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query, name, string(dataXML), now, now)
+	result, err := r.db.Exec(query, name, string(dataXML), now, now, string(devicesJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert GPX record: %w", err)
 	}
@@ -81,31 +136,47 @@ func (r *GPXRepo) Create(name string, gpxData gpx.Gpx) (*GPXRecord, error) {
 		Data:      string(dataXML),
 		CreatedAt: now,
 		UpdatedAt: now,
+		Devices:   devices,
 	}, nil
 }
 
 func (r *GPXRepo) Get(id int64) (*GPXRecord, error) {
 	query := `
-	SELECT id, name, data, created_at, updated_at
+	SELECT id, name, data, created_at, updated_at, devices
 	FROM gpx_files
 	WHERE id = ?
 	`
 
+	record, err := scanGPXRecord(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("GPX record with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get GPX record: %w", err)
+	}
+
+	return record, nil
+}
+
+// scanGPXRecord scans a row with all columns of gpx_files
+func scanGPXRecord(row scanner) (*GPXRecord, error) {
 	record := &GPXRecord{}
-	err := r.db.QueryRow(query, id).Scan(
+	var devices string
+	err := row.Scan(
 		&record.ID,
 		&record.Name,
 		&record.Data,
 		&record.CreatedAt,
 		&record.UpdatedAt,
+		&devices,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("GPX record with ID %d not found", id)
-		}
-		return nil, fmt.Errorf("failed to get GPX record: %w", err)
+		return nil, err
 	}
 
+	if err := json.Unmarshal([]byte(devices), &record.Devices); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal devices: %w", err)
+	}
 	return record, nil
 }
 
@@ -126,7 +197,7 @@ func (r *GPXRepo) GetGPX(id int64) (*gpx.Gpx, error) {
 
 func (r *GPXRepo) GetAll() ([]*GPXRecord, error) {
 	query := `
-	SELECT id, name, data, created_at, updated_at
+	SELECT id, name, data, created_at, updated_at, devices
 	FROM gpx_files
 	ORDER BY created_at DESC
 	`
@@ -139,14 +210,7 @@ func (r *GPXRepo) GetAll() ([]*GPXRecord, error) {
 
 	var records []*GPXRecord
 	for rows.Next() {
-		record := &GPXRecord{}
-		err := rows.Scan(
-			&record.ID,
-			&record.Name,
-			&record.Data,
-			&record.CreatedAt,
-			&record.UpdatedAt,
-		)
+		record, err := scanGPXRecord(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan GPX record: %w", err)
 		}
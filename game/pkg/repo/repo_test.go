@@ -0,0 +1,75 @@
+package repo_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"overlay/pkg/gpx"
+	"overlay/pkg/repo"
+)
+
+func TestGPXRepoDevices(t *testing.T) {
+	r, err := repo.NewGPXRepo(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+
+	devices := []repo.RideDevice{
+		{Role: "trainer", Name: "KICKR", Address: "AA:BB:CC:DD:EE:FF", Manufacturer: "Wahoo", Firmware: "4.2.1", Battery: -1},
+		{Role: "heart rate monitor", Name: "Polar H10", Address: "11:22:33:44:55:66", Battery: 15},
+	}
+	created, err := r.Create("ride", gpx.New("ride"), devices...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := r.Get(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(record.Devices, devices) {
+		t.Errorf("expected the devices of the ride %+v, got %+v", devices, record.Devices)
+	}
+}
+
+func TestGPXRepoMigratesDevices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+
+	// a database from before the devices were stored
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE gpx_files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	INSERT INTO gpx_files (name, data, created_at, updated_at) VALUES ('old ride', '', ?, ?);`,
+		time.Now(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	r, err := repo.NewGPXRepo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+
+	records, err := r.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "old ride" || len(records[0].Devices) != 0 {
+		t.Errorf("expected the old ride without devices, got %+v", records)
+	}
+}
@@ -0,0 +1,368 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"overlay/internal/workout"
+
+	_ "modernc.org/sqlite"
+)
+
+type WorkoutRepo struct {
+	db *sql.DB
+}
+
+type WorkoutRecord struct {
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Workout      workout.Workout `json:"workout"`
+	Tags         []string        `json:"tags"`
+	Favourite    bool            `json:"favourite"`
+	LastRiddenAt *time.Time      `json:"last_ridden_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// NewWorkoutRepo opens the workout library, it can
+// share the database with the GPXRepo.
+func NewWorkoutRepo(dbPath string) (*WorkoutRepo, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	repo := &WorkoutRepo{db: db}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create table: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *WorkoutRepo) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS workouts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		favourite BOOLEAN NOT NULL DEFAULT FALSE,
+		last_ridden_at DATETIME,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS workout_tags (
+		workout_id INTEGER NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (workout_id, tag)
+	);`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *WorkoutRepo) Create(w workout.Workout, tags ...string) (*WorkoutRecord, error) {
+	dataJSON, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workout: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// a workout is stored with all of its tags or not at all
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now().Round(0)
+	query := `
+	INSERT INTO workouts (name, data, created_at, updated_at)
+	VALUES (?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query, w.Name, string(dataJSON), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert workout record: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	for _, tag := range tags {
+		if err := addTag(tx, id, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.Get(id)
+}
+
+func (r *WorkoutRepo) Get(id int64) (*WorkoutRecord, error) {
+	query := `
+	SELECT id, name, data, favourite, last_ridden_at, created_at, updated_at
+	FROM workouts
+	WHERE id = ?
+	`
+
+	record, err := r.scan(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("workout record with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get workout record: %w", err)
+	}
+
+	record.Tags, err = r.tags(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetWorkout returns the workout stored with the given id
+func (r *WorkoutRepo) GetWorkout(id int64) (*workout.Workout, error) {
+	record, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record.Workout, nil
+}
+
+func (r *WorkoutRepo) GetAll() ([]*WorkoutRecord, error) {
+	query := `
+	SELECT id, name, data, favourite, last_ridden_at, created_at, updated_at
+	FROM workouts
+	ORDER BY created_at DESC
+	`
+
+	return r.query(query)
+}
+
+// GetFavourites returns the workouts marked as favourite
+func (r *WorkoutRepo) GetFavourites() ([]*WorkoutRecord, error) {
+	query := `
+	SELECT id, name, data, favourite, last_ridden_at, created_at, updated_at
+	FROM workouts
+	WHERE favourite
+	ORDER BY created_at DESC
+	`
+
+	return r.query(query)
+}
+
+// GetByTag returns the workouts having the given tag
+func (r *WorkoutRepo) GetByTag(tag string) ([]*WorkoutRecord, error) {
+	query := `
+	SELECT w.id, w.name, w.data, w.favourite, w.last_ridden_at, w.created_at, w.updated_at
+	FROM workouts w
+	JOIN workout_tags t ON t.workout_id = w.id
+	WHERE t.tag = ?
+	ORDER BY w.created_at DESC
+	`
+
+	return r.query(query, tag)
+}
+
+func (r *WorkoutRepo) Update(id int64, w workout.Workout) (*WorkoutRecord, error) {
+	dataJSON, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workout: %w", err)
+	}
+
+	query := `
+	UPDATE workouts
+	SET name = ?, data = ?, updated_at = ?
+	WHERE id = ?
+	`
+
+	if err := exec(r.db, id, query, w.Name, string(dataJSON), time.Now().Round(0), id); err != nil {
+		return nil, fmt.Errorf("failed to update workout record: %w", err)
+	}
+
+	return r.Get(id)
+}
+
+func (r *WorkoutRepo) Delete(id int64) error {
+	tx, err := r.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// the tags must not outlive their workout
+	defer func() { _ = tx.Rollback() }()
+
+	if err := exec(tx, id, `DELETE FROM workouts WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete workout record: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workout_tags WHERE workout_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete workout tags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WorkoutRepo) SetFavourite(id int64, favourite bool) error {
+	query := `UPDATE workouts SET favourite = ? WHERE id = ?`
+	if err := exec(r.db, id, query, favourite, id); err != nil {
+		return fmt.Errorf("failed to update favourite: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRidden stores when the workout was last ridden
+func (r *WorkoutRepo) MarkRidden(id int64, at time.Time) error {
+	query := `UPDATE workouts SET last_ridden_at = ? WHERE id = ?`
+	if err := exec(r.db, id, query, at.Round(0), id); err != nil {
+		return fmt.Errorf("failed to update last ridden: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WorkoutRepo) AddTag(id int64, tag string) error {
+	return addTag(r.db, id, tag)
+}
+
+func addTag(db execer, id int64, tag string) error {
+	query := `INSERT OR IGNORE INTO workout_tags (workout_id, tag) VALUES (?, ?)`
+	if _, err := db.Exec(query, id, tag); err != nil {
+		return fmt.Errorf("failed to add tag: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WorkoutRepo) RemoveTag(id int64, tag string) error {
+	query := `DELETE FROM workout_tags WHERE workout_id = ? AND tag = ?`
+	if _, err := r.db.Exec(query, id, tag); err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WorkoutRepo) Close() error {
+	return r.db.Close()
+}
+
+// execer runs queries on the database or in a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// exec runs a query that should change the workout with the given id
+func exec(db execer, id int64, query string, args ...any) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("workout record with ID %d not found", id)
+	}
+
+	return nil
+}
+
+func (r *WorkoutRepo) query(query string, args ...any) ([]*WorkoutRecord, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workout records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*WorkoutRecord
+	for rows.Next() {
+		record, err := r.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workout record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating workout records: %w", err)
+	}
+
+	for _, record := range records {
+		record.Tags, err = r.tags(record.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func (r *WorkoutRepo) scan(row scanner) (*WorkoutRecord, error) {
+	record := &WorkoutRecord{}
+	var data string
+	var lastRidden sql.NullTime
+	err := row.Scan(
+		&record.ID,
+		&record.Name,
+		&data,
+		&record.Favourite,
+		&lastRidden,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastRidden.Valid {
+		record.LastRiddenAt = &lastRidden.Time
+	}
+
+	if err := json.Unmarshal([]byte(data), &record.Workout); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workout: %w", err)
+	}
+
+	return record, nil
+}
+
+func (r *WorkoutRepo) tags(id int64) ([]string, error) {
+	rows, err := r.db.Query(`SELECT tag FROM workout_tags WHERE workout_id = ? ORDER BY tag`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
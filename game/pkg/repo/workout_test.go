@@ -0,0 +1,135 @@
+package repo_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"overlay/internal/workout"
+	"overlay/pkg/repo"
+)
+
+func newWorkoutRepo(t *testing.T) *repo.WorkoutRepo {
+	r, err := repo.NewWorkoutRepo(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestWorkoutRepoCRUD(t *testing.T) {
+	r := newWorkoutRepo(t)
+
+	w, err := workout.FromString("Intervals;250;60%-75%-600;5x(120%-120%-180;50%-50%-120)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := r.Create(*w, "vo2max", "short")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := r.GetWorkout(record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if workout.Duration(*stored) != workout.Duration(*w) || workout.MaxPower(*stored) != workout.MaxPower(*w) {
+		t.Errorf("Stored workout should equal the created workout, got %+v", stored)
+	}
+
+	if len(record.Tags) != 2 {
+		t.Errorf("Should have two tags, got %v", record.Tags)
+	}
+
+	w.Name = "Renamed"
+	updated, err := r.Update(record.ID, *w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated.Name != "Renamed" {
+		t.Errorf("Name should be updated, got %s", updated.Name)
+	}
+
+	if err := r.Delete(record.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Get(record.ID); err == nil {
+		t.Error("Workout should be deleted")
+	}
+
+	tagged, err := r.GetByTag("vo2max")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tagged) != 0 {
+		t.Errorf("Tags should be deleted with the workout, got %v", tagged)
+	}
+
+	if err := r.Delete(record.ID); err == nil {
+		t.Error("Deleting a missing workout should fail")
+	}
+}
+
+func TestWorkoutRepoLibrary(t *testing.T) {
+	r := newWorkoutRepo(t)
+
+	w := workout.NewRandom()
+	first, err := r.Create(*w, "endurance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := r.Create(*w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetFavourite(second.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	favourites, err := r.GetFavourites()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(favourites) != 1 || favourites[0].ID != second.ID {
+		t.Errorf("Only the second workout should be a favourite, got %v", favourites)
+	}
+
+	tagged, err := r.GetByTag("endurance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tagged) != 1 || tagged[0].ID != first.ID {
+		t.Errorf("Only the first workout should be tagged, got %v", tagged)
+	}
+
+	if first.LastRiddenAt != nil {
+		t.Error("New workout should not be ridden")
+	}
+
+	ridden := time.Now()
+	if err := r.MarkRidden(first.ID, ridden); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := r.Get(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.LastRiddenAt == nil || !record.LastRiddenAt.Equal(ridden.Round(0)) {
+		t.Errorf("Last ridden should be %s, got %v", ridden, record.LastRiddenAt)
+	}
+
+	if err := r.SetFavourite(42, true); err == nil {
+		t.Error("Should fail for an unknown workout")
+	}
+}
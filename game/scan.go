@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"overlay/pkg/bluetooth"
+)
+
+// scan prints the nearby bluetooth devices,
+// their name or address can be passed to -trainer and -hr
+func scan() error {
+	peripherals, err := bluetooth.Scan(*scanTimeout)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		return json.NewEncoder(os.Stdout).Encode(peripherals)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS\tRSSI\tSERVICES")
+	for _, p := range peripherals {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", p.Name, p.Address, p.RSSI, strings.Join(p.Services, ","))
+	}
+
+	return w.Flush()
+}
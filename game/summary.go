@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"overlay/internal/workout"
+	"overlay/pkg/repo"
+)
+
+var jsonOutput = flag.Bool("json", false, "print the output of a command as JSON")
+
+// summaryOutput is the JSON representation of a workout summary,
+// durations are in seconds
+type summaryOutput struct {
+	Name                string         `json:"name"`
+	FTP                 int            `json:"ftp"`
+	Duration            int            `json:"duration"`
+	NormalizedPower     float64        `json:"normalized_power"`
+	IntensityFactor     float64        `json:"intensity_factor"`
+	TrainingStressScore float64        `json:"training_stress_score"`
+	Work                float64        `json:"work_kj"`
+	TimeInZones         map[string]int `json:"time_in_zones"`
+}
+
+// summary prints the planned metrics of the selected workout
+func summary() error {
+	workouts, err := repo.NewWorkoutRepo(dbPath())
+	if err != nil {
+		return err
+	}
+	defer workouts.Close()
+
+	training, err := loadWorkout(workouts)
+	if err != nil {
+		return err
+	}
+
+	s := workout.Summarize(*training)
+	if *jsonOutput {
+		out := summaryOutput{
+			Name:                training.Name,
+			FTP:                 training.FTP,
+			Duration:            int(s.Duration / time.Second),
+			NormalizedPower:     s.NormalizedPower,
+			IntensityFactor:     s.IntensityFactor,
+			TrainingStressScore: s.TrainingStressScore,
+			Work:                s.Work,
+			TimeInZones:         map[string]int{},
+		}
+		for i, z := range workout.Zones {
+			out.TimeInZones[z.Name] = int(s.TimeInZones[i] / time.Second)
+		}
+
+		return json.NewEncoder(os.Stdout).Encode(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Workout\t%s\n", training.Name)
+	fmt.Fprintf(w, "FTP\t%d W\n", training.FTP)
+	fmt.Fprintf(w, "Duration\t%s\n", s.Duration)
+	fmt.Fprintf(w, "NP\t%.0f W\n", s.NormalizedPower)
+	fmt.Fprintf(w, "IF\t%.2f\n", s.IntensityFactor)
+	fmt.Fprintf(w, "TSS\t%.0f\n", s.TrainingStressScore)
+	fmt.Fprintf(w, "Work\t%.0f kJ\n", s.Work)
+	for i, z := range workout.Zones {
+		fmt.Fprintf(w, "%s\t%s\n", z.Name, s.TimeInZones[i])
+	}
+
+	return w.Flush()
+}